@@ -0,0 +1,187 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package atomix
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"sync"
+	"time"
+)
+
+// newRetryBudget creates a new retry budget holding at most the given number of tokens, with one token
+// added per interval
+func newRetryBudget(tokens int, interval time.Duration) *retryBudget {
+	return &retryBudget{
+		capacity: float64(tokens),
+		tokens:   float64(tokens),
+		interval: interval,
+		last:     time.Now(),
+	}
+}
+
+// retryBudget is a token bucket shared by all calls made by a client
+// The first attempt of each call is free, and each retry attempt takes a token from the bucket. Once the
+// bucket is empty, retries are skipped until tokens are added.
+type retryBudget struct {
+	capacity float64
+	tokens   float64
+	interval time.Duration
+	last     time.Time
+	mu       sync.Mutex
+}
+
+// take takes a token from the budget, returning false if the budget is exhausted
+func (b *retryBudget) take() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	if b.interval > 0 {
+		b.tokens += float64(now.Sub(b.last)) / float64(b.interval)
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// errRetryBudgetExhausted is returned to the retrying interceptor to stop retries once the budget is exhausted
+var errRetryBudgetExhausted = status.Error(codes.ResourceExhausted, "retry budget exhausted")
+
+// retryCallKey is the context key for the state of a call
+type retryCallKey struct{}
+
+// retryCall tracks the attempts of a single call
+type retryCall struct {
+	attempts  int
+	err       error
+	exhausted bool
+	mu        sync.Mutex
+}
+
+// attempt records an attempt of the call, returning false if the attempt is a retry and the budget is exhausted
+func (c *retryCall) attempt(budget *retryBudget) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.attempts++
+	c.exhausted = c.attempts > 1 && !budget.take()
+	return !c.exhausted
+}
+
+// fail records the error returned by an attempt of the call
+func (c *retryCall) fail(err error) {
+	if err != nil && err != io.EOF {
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+	}
+}
+
+// error returns the error to return for the call, replacing the error that stopped retries with the error
+// returned by the last attempt
+func (c *retryCall) error(err error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil && c.exhausted && c.err != nil {
+		return c.err
+	}
+	return err
+}
+
+// callInterceptors returns the interceptors to run before the retrying interceptors
+func (b *retryBudget) callInterceptors() (grpc.UnaryClientInterceptor, grpc.StreamClientInterceptor) {
+	unary := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		call := &retryCall{}
+		err := invoker(context.WithValue(ctx, retryCallKey{}, call), method, req, reply, cc, opts...)
+		return call.error(err)
+	}
+	stream := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		call := &retryCall{}
+		s, err := streamer(context.WithValue(ctx, retryCallKey{}, call), desc, cc, method, opts...)
+		if err != nil {
+			return nil, call.error(err)
+		}
+		return &retryBudgetStream{
+			ClientStream: s,
+			call:         call,
+		}, nil
+	}
+	return unary, stream
+}
+
+// attemptInterceptors returns the interceptors to run for each attempt made by the retrying interceptors
+func (b *retryBudget) attemptInterceptors() (grpc.UnaryClientInterceptor, grpc.StreamClientInterceptor) {
+	unary := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		call, ok := ctx.Value(retryCallKey{}).(*retryCall)
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		if !call.attempt(b) {
+			return errRetryBudgetExhausted
+		}
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		call.fail(err)
+		return err
+	}
+	stream := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		call, ok := ctx.Value(retryCallKey{}).(*retryCall)
+		if !ok {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+		if !call.attempt(b) {
+			return nil, errRetryBudgetExhausted
+		}
+		s, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			call.fail(err)
+			return nil, err
+		}
+		return &retryAttemptStream{
+			ClientStream: s,
+			call:         call,
+		}, nil
+	}
+	return unary, stream
+}
+
+// retryBudgetStream is a client stream that returns the last attempt's error if retries were stopped by the budget
+type retryBudgetStream struct {
+	grpc.ClientStream
+	call *retryCall
+}
+
+func (s *retryBudgetStream) SendMsg(m interface{}) error {
+	return s.call.error(s.ClientStream.SendMsg(m))
+}
+
+func (s *retryBudgetStream) RecvMsg(m interface{}) error {
+	return s.call.error(s.ClientStream.RecvMsg(m))
+}
+
+// retryAttemptStream is a client stream that records the errors returned by an attempt
+type retryAttemptStream struct {
+	grpc.ClientStream
+	call *retryCall
+}
+
+func (s *retryAttemptStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	s.call.fail(err)
+	return err
+}
+
+func (s *retryAttemptStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	s.call.fail(err)
+	return err
+}
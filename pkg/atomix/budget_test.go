@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package atomix
+
+import (
+	"context"
+	mapapi "github.com/atomix/atomix-api/go/atomix/primitive/map"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testUnavailableMapServer is a map server that is always unavailable
+type testUnavailableMapServer struct {
+	mapapi.UnimplementedMapServiceServer
+	requests int32
+}
+
+func (s *testUnavailableMapServer) Size(ctx context.Context, request *mapapi.SizeRequest) (*mapapi.SizeResponse, error) {
+	atomic.AddInt32(&s.requests, 1)
+	return nil, status.Error(codes.Unavailable, "unavailable")
+}
+
+func TestRetryBudget(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := grpc.NewServer()
+	mapServer := &testUnavailableMapServer{}
+	mapapi.RegisterMapServiceServer(server, mapServer)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	defer server.Stop()
+
+	client := NewClient(WithRetryBudget(3, 500*time.Millisecond)).(*atomixClient)
+	conn, err := grpc.Dial(lis.Addr().String(), client.getPrimitiveDialOptions()...)
+	assert.NoError(t, err)
+	defer conn.Close()
+	mapClient := mapapi.NewMapServiceClient(conn)
+
+	// Retries draw from the budget until it is depleted, and the last attempt's error is returned
+	_, err = mapClient.Size(context.Background(), &mapapi.SizeRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, int32(4), atomic.LoadInt32(&mapServer.requests))
+
+	// Once the budget is depleted, requests are not retried
+	_, err = mapClient.Size(context.Background(), &mapapi.SizeRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, int32(5), atomic.LoadInt32(&mapServer.requests))
+
+	// Retries resume once the budget is refilled
+	time.Sleep(600 * time.Millisecond)
+	_, err = mapClient.Size(context.Background(), &mapapi.SizeRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, int32(7), atomic.LoadInt32(&mapServer.requests))
+}
@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package atomix
+
+import (
+	"context"
+	"github.com/atomix/atomix-go-client/pkg/atomix/counter"
+	"github.com/atomix/atomix-go-client/pkg/atomix/election"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
+	"github.com/atomix/atomix-go-client/pkg/atomix/indexedmap"
+	"github.com/atomix/atomix-go-client/pkg/atomix/list"
+	"github.com/atomix/atomix-go-client/pkg/atomix/lock"
+	_map "github.com/atomix/atomix-go-client/pkg/atomix/map"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"github.com/atomix/atomix-go-client/pkg/atomix/set"
+	"github.com/atomix/atomix-go-client/pkg/atomix/value"
+	"strings"
+	"time"
+)
+
+// NewBuilder creates a new Builder of primitives created by the given client
+func NewBuilder(client Client) *Builder {
+	return &Builder{
+		client: client,
+	}
+}
+
+// Builder assembles the configuration of a primitive
+// Configuration is validated when the primitive is built, and Build fails with an error matching
+// errors.ErrInvalidArgument if the configuration is invalid, e.g. if the primitive type or name is missing.
+// Use the AsCounter, AsMap, etc functions to convert the built primitive to its type.
+type Builder struct {
+	client        Client
+	primitiveType primitive.Type
+	name          string
+	opts          []primitive.Option
+	errs          []string
+}
+
+// WithType sets the type of the primitive to build
+func (b *Builder) WithType(t primitive.Type) *Builder {
+	b.primitiveType = t
+	return b
+}
+
+// WithName sets the name of the primitive to build
+func (b *Builder) WithName(name string) *Builder {
+	b.name = name
+	return b
+}
+
+// WithSessionID sets the primitive session identifier
+func (b *Builder) WithSessionID(sessionID string) *Builder {
+	if sessionID == "" {
+		b.errs = append(b.errs, "session ID must not be empty")
+	}
+	return b.WithOption(primitive.WithSessionID(sessionID))
+}
+
+// WithClusterKey sets the primitive cluster key
+func (b *Builder) WithClusterKey(clusterKey string) *Builder {
+	return b.WithOption(primitive.WithClusterKey(clusterKey))
+}
+
+// WithTenant sets the tenant on whose behalf the primitive's requests are made
+func (b *Builder) WithTenant(id string) *Builder {
+	if id == "" {
+		b.errs = append(b.errs, "tenant must not be empty")
+	}
+	return b.WithOption(WithTenant(id))
+}
+
+// WithCreateRetry retries failed attempts to create the primitive while the service is unavailable
+func (b *Builder) WithCreateRetry(attempts int, backoff time.Duration) *Builder {
+	if attempts <= 0 {
+		b.errs = append(b.errs, "create attempts must be positive")
+	}
+	if backoff < 0 {
+		b.errs = append(b.errs, "create backoff must not be negative")
+	}
+	return b.WithOption(primitive.WithCreateRetry(attempts, backoff))
+}
+
+// WithPanicRecovery sets whether panics raised by user callbacks are recovered
+func (b *Builder) WithPanicRecovery(enabled bool) *Builder {
+	return b.WithOption(primitive.WithPanicRecovery(enabled))
+}
+
+// WithOption adds the given option to the primitive, e.g. an option specific to the primitive type
+func (b *Builder) WithOption(opt primitive.Option) *Builder {
+	b.opts = append(b.opts, opt)
+	return b
+}
+
+// validate returns an error describing the invalid configuration, if any
+func (b *Builder) validate() error {
+	errs := b.errs
+	if b.primitiveType == "" {
+		errs = append(errs, "type is required")
+	}
+	if b.name == "" {
+		errs = append(errs, "name is required")
+	}
+	if len(errs) > 0 {
+		return errors.NewInvalid("invalid primitive configuration: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Build validates the configuration and creates the primitive
+func (b *Builder) Build(ctx context.Context) (primitive.Primitive, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+	switch b.primitiveType {
+	case counter.Type:
+		return b.client.GetCounter(ctx, b.name, b.opts...)
+	case election.Type:
+		return b.client.GetElection(ctx, b.name, b.opts...)
+	case indexedmap.Type:
+		return b.client.GetIndexedMap(ctx, b.name, b.opts...)
+	case list.Type:
+		return b.client.GetList(ctx, b.name, b.opts...)
+	case lock.Type:
+		return b.client.GetLock(ctx, b.name, b.opts...)
+	case _map.Type:
+		return b.client.GetMap(ctx, b.name, b.opts...)
+	case set.Type:
+		return b.client.GetSet(ctx, b.name, b.opts...)
+	case value.Type:
+		return b.client.GetValue(ctx, b.name, b.opts...)
+	}
+	return nil, errors.NewInvalid("invalid primitive configuration: unknown type %s", b.primitiveType)
+}
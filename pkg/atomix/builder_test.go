@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package atomix
+
+import (
+	"context"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
+	_map "github.com/atomix/atomix-go-client/pkg/atomix/map"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// testBuilderMap is a map that records the options with which it was created
+type testBuilderMap struct {
+	_map.Map
+	name string
+	opts []primitive.Option
+}
+
+func (m *testBuilderMap) Type() primitive.Type {
+	return _map.Type
+}
+
+func (m *testBuilderMap) Name() string {
+	return m.name
+}
+
+// testBuilderClient is a client that creates testBuilderMaps
+type testBuilderClient struct {
+	Client
+}
+
+func (c *testBuilderClient) GetMap(ctx context.Context, name string, opts ...primitive.Option) (_map.Map, error) {
+	return &testBuilderMap{name: name, opts: opts}, nil
+}
+
+func TestBuilder(t *testing.T) {
+	client := &testBuilderClient{}
+
+	// A fully configured primitive is created with all of its options
+	p, err := NewBuilder(client).
+		WithType(_map.Type).
+		WithName("foo").
+		WithSessionID("bar").
+		WithClusterKey("baz").
+		WithTenant("qux").
+		WithCreateRetry(3, time.Second).
+		WithPanicRecovery(false).
+		WithOption(_map.WithWatchHistory(10)).
+		Build(context.Background())
+	assert.NoError(t, err)
+	m, ok := AsMap(p)
+	assert.True(t, ok)
+	assert.Equal(t, "foo", m.Name())
+	opts := m.(*testBuilderMap).opts
+	assert.Len(t, opts, 6)
+	assert.Equal(t, WithTenant("qux"), opts[2])
+	assert.Equal(t, _map.WithWatchHistory(10), opts[5])
+
+	// Missing required fields fail the build
+	_, err = NewBuilder(client).Build(context.Background())
+	assert.True(t, errors.IsInvalid(err))
+	assert.Contains(t, err.Error(), "type is required")
+	assert.Contains(t, err.Error(), "name is required")
+
+	// Invalid options fail the build rather than the first operation
+	_, err = NewBuilder(client).
+		WithType(_map.Type).
+		WithName("foo").
+		WithCreateRetry(0, time.Second).
+		Build(context.Background())
+	assert.True(t, errors.IsInvalid(err))
+	assert.Contains(t, err.Error(), "create attempts must be positive")
+
+	_, err = NewBuilder(client).
+		WithType("Queue").
+		WithName("foo").
+		Build(context.Background())
+	assert.True(t, errors.IsInvalid(err))
+}
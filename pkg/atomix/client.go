@@ -11,23 +11,29 @@ import (
 	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
 	"github.com/atomix/atomix-go-client/pkg/atomix/counter"
 	"github.com/atomix/atomix-go-client/pkg/atomix/election"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-client/pkg/atomix/indexedmap"
 	"github.com/atomix/atomix-go-client/pkg/atomix/list"
 	"github.com/atomix/atomix-go-client/pkg/atomix/lock"
 	_map "github.com/atomix/atomix-go-client/pkg/atomix/map"
 	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
 	"github.com/atomix/atomix-go-client/pkg/atomix/set"
+	"github.com/atomix/atomix-go-client/pkg/atomix/util"
 	"github.com/atomix/atomix-go-client/pkg/atomix/value"
-	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/util/retry"
 	"github.com/google/uuid"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
 	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+var log = logging.GetLogger("atomix", "client")
+
 // GetCounter gets the Counter instance of the given name
 func GetCounter(ctx context.Context, name string, opts ...primitive.Option) (counter.Counter, error) {
 	return getClient().GetCounter(ctx, name, opts...)
@@ -68,20 +74,43 @@ func GetValue(ctx context.Context, name string, opts ...primitive.Option) (value
 	return getClient().GetValue(ctx, name, opts...)
 }
 
+// Exists checks whether a primitive of the given type and name exists
+func Exists(ctx context.Context, t primitive.Type, name string) (bool, error) {
+	return getClient().Exists(ctx, t, name)
+}
+
+// Warmup establishes the client's connections
+func Warmup(ctx context.Context) error {
+	return getClient().Warmup(ctx)
+}
+
 // NewClient creates a new Atomix client
 func NewClient(opts ...Option) Client {
 	options := clientOptions{
-		clientID:   uuid.New().String(),
-		brokerHost: defaultHost,
-		brokerPort: defaultPort,
+		clientID:        uuid.New().String(),
+		brokerHost:      defaultHost,
+		brokerPort:      defaultPort,
+		shutdownTimeout: defaultShutdownTimeout,
 	}
 	for _, opt := range opts {
 		opt.apply(&options)
 	}
-	return &atomixClient{
+	client := &atomixClient{
 		options:        options,
-		primitiveConns: make(map[primitiveapi.PrimitiveId]*grpc.ClientConn),
+		primitiveConns: make(map[primitiveConnKey]*grpc.ClientConn),
+		primitives:     make(map[primitiveConnKey]*registration),
+		streams:        newStreamTracker(),
+	}
+	if options.retryBudget != nil {
+		client.retryBudget = newRetryBudget(options.retryBudget.tokens, options.retryBudget.interval)
 	}
+	if options.maxInFlight > 0 {
+		client.inFlight = newSessionInFlightLimiters(options.maxInFlight)
+	}
+	if options.slowThreshold > 0 {
+		client.slowOps = newSlowOperationLogger(options.slowThreshold)
+	}
+	return client
 }
 
 // Client is an Atomix client
@@ -95,18 +124,165 @@ type Client interface {
 	set.Client
 	value.Client
 	io.Closer
+
+	// Exists checks whether a primitive of the given type and name exists
+	// The primitive is looked up by the broker without creating a session for it.
+	Exists(ctx context.Context, t primitive.Type, name string) (bool, error)
+
+	// Warmup establishes the client's connections
+	// Warmup connects to the broker and waits for the broker connection and the connections of any primitives
+	// already created by the client to become ready, so subsequent operations do not pay connection setup
+	// latency. If the connections are not ready before the context is done, a Timeout or Canceled error
+	// is returned.
+	Warmup(ctx context.Context) error
+
+	// Broadcast invokes the given function against each of the client's primitive connections
+	// The function is invoked concurrently for each connection the client has opened, and every connection is
+	// visited even if an invocation fails. The returned slice holds the result of each invocation in the order
+	// in which the connections were opened; connections not visited before the context is done hold the
+	// context's error.
+	Broadcast(ctx context.Context, fn func(conn *grpc.ClientConn) error) []error
 }
 
 type atomixClient struct {
 	options        clientOptions
+	retryBudget    *retryBudget
+	inFlight       *sessionInFlightLimiters
+	slowOps        *slowOperationLogger
 	brokerConn     *grpc.ClientConn
-	primitiveConns map[primitiveapi.PrimitiveId]*grpc.ClientConn
+	brokerMu       sync.Mutex
+	primitiveConns map[primitiveConnKey]*grpc.ClientConn
+	connKeys       []primitiveConnKey
+	primitives     map[primitiveConnKey]*registration
+	primitiveKeys  []primitiveConnKey
+	streams        *streamTracker
+	closed         int32
 	mu             sync.RWMutex
 }
 
-func (c *atomixClient) connect(ctx context.Context, primitive primitiveapi.PrimitiveId) (*grpc.ClientConn, error) {
+// primitiveConnKey is the key of a primitive connection
+// Primitives of the same name for different tenants or sessions use separate connections.
+type primitiveConnKey struct {
+	primitive primitiveapi.PrimitiveId
+	tenant    string
+	session   string
+}
+
+// getBrokerConn returns the broker connection, connecting to the broker if necessary
+func (c *atomixClient) getBrokerConn(ctx context.Context) (*grpc.ClientConn, error) {
+	c.brokerMu.Lock()
+	defer c.brokerMu.Unlock()
+	if c.isClosed() {
+		return nil, errors.From(grpc.ErrClientConnClosing)
+	}
+	if c.brokerConn == nil {
+		conn, err := grpc.DialContext(ctx, fmt.Sprintf("%s:%d", c.options.brokerHost, c.options.brokerPort),
+			c.getBrokerDialOptions()...)
+		if err != nil {
+			return nil, err
+		}
+		c.brokerConn = conn
+	}
+	return c.brokerConn, nil
+}
+
+func (c *atomixClient) Exists(ctx context.Context, t primitive.Type, name string) (bool, error) {
+	brokerConn, err := c.getBrokerConn(ctx)
+	if err != nil {
+		return false, err
+	}
+	brokerClient := brokerapi.NewBrokerClient(brokerConn)
+	request := &brokerapi.LookupPrimitiveRequest{
+		PrimitiveID: brokerapi.PrimitiveId{
+			PrimitiveId: newPrimitiveID(t, name),
+		},
+	}
+	_, err = brokerClient.LookupPrimitive(ctx, request)
+	if err != nil {
+		err = errors.From(err)
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *atomixClient) Warmup(ctx context.Context) error {
+	brokerConn, err := c.getBrokerConn(ctx)
+	if err != nil {
+		return err
+	}
+	conns := []*grpc.ClientConn{brokerConn}
 	c.mu.RLock()
-	driverConn, ok := c.primitiveConns[primitive]
+	for _, conn := range c.primitiveConns {
+		conns = append(conns, conn)
+	}
+	c.mu.RUnlock()
+	for _, conn := range conns {
+		if err := waitForReady(ctx, conn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *atomixClient) Broadcast(ctx context.Context, fn func(conn *grpc.ClientConn) error) []error {
+	c.mu.RLock()
+	conns := make([]*grpc.ClientConn, len(c.connKeys))
+	for i, key := range c.connKeys {
+		conns[i] = c.primitiveConns[key]
+	}
+	c.mu.RUnlock()
+
+	errs := make([]error, len(conns))
+	err := util.ForEachPartition(ctx, conns, 0, func(i int, conn *grpc.ClientConn) error {
+		return fn(conn)
+	})
+	if partitionErrs, ok := err.(util.PartitionErrors); ok {
+		for _, partitionErr := range partitionErrs {
+			errs[partitionErr.Partition] = partitionErr.Err
+		}
+	}
+	return errs
+}
+
+// waitForReady waits for the given connection to become ready
+func waitForReady(ctx context.Context, conn *grpc.ClientConn) error {
+	for {
+		state := conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			return nil
+		case connectivity.Shutdown:
+			return errors.From(grpc.ErrClientConnClosing)
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			return errors.From(ctx.Err())
+		}
+	}
+}
+
+// getConnKey returns the connection key for the given primitive
+func (c *atomixClient) getConnKey(id primitiveapi.PrimitiveId, opts ...primitive.Option) primitiveConnKey {
+	key := primitiveConnKey{
+		primitive: id,
+		tenant:    c.options.tenant,
+		session:   primitive.GetSessionID(getPrimitiveOpts(c.options, opts...)...),
+	}
+	for _, opt := range opts {
+		if tenant, ok := opt.(TenantOption); ok {
+			key.tenant = tenant.tenant
+		}
+	}
+	return key
+}
+
+func (c *atomixClient) connect(ctx context.Context, primitive primitiveapi.PrimitiveId, opts ...primitive.Option) (*grpc.ClientConn, error) {
+	key := c.getConnKey(primitive, opts...)
+
+	c.mu.RLock()
+	driverConn, ok := c.primitiveConns[key]
 	c.mu.RUnlock()
 	if ok {
 		return driverConn, nil
@@ -115,45 +291,131 @@ func (c *atomixClient) connect(ctx context.Context, primitive primitiveapi.Primi
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	driverConn, ok = c.primitiveConns[primitive]
+	if c.isClosed() {
+		return nil, errors.From(grpc.ErrClientConnClosing)
+	}
+	driverConn, ok = c.primitiveConns[key]
 	if ok {
 		return driverConn, nil
 	}
 
-	brokerConn := c.brokerConn
-	if brokerConn == nil {
-		conn, err := grpc.DialContext(ctx, fmt.Sprintf("%s:%d", c.options.brokerHost, c.options.brokerPort),
-			grpc.WithInsecure(),
-			grpc.WithUnaryInterceptor(retry.RetryingUnaryClientInterceptor(retry.WithRetryOn(codes.Unavailable))))
-		if err != nil {
-			return nil, err
-		}
-		c.brokerConn = conn
-		brokerConn = conn
+	brokerConn, err := c.getBrokerConn(ctx)
+	if err != nil {
+		return nil, err
 	}
 
+	// The lookup carries the primitive's tenant, which may override the client's tenant
+	lookupCtx := ctx
+	if key.tenant != "" {
+		lookupCtx = withTenant(ctx, key.tenant)
+	}
 	brokerClient := brokerapi.NewBrokerClient(brokerConn)
 	request := &brokerapi.LookupPrimitiveRequest{
 		PrimitiveID: brokerapi.PrimitiveId{
 			PrimitiveId: primitive,
 		},
 	}
-	response, err := brokerClient.LookupPrimitive(ctx, request, retry.WithRetryOn(codes.Unavailable, codes.NotFound), retry.WithPerCallTimeout(time.Second))
+	response, err := brokerClient.LookupPrimitive(lookupCtx, request, retry.WithRetryOn(codes.Unavailable, codes.NotFound), retry.WithPerCallTimeout(time.Second))
 	if err != nil {
 		return nil, errors.From(err)
 	}
 
-	driverConn, err = grpc.DialContext(ctx, fmt.Sprintf("%s:%d", response.Address.Host, response.Address.Port),
-		grpc.WithInsecure(),
-		grpc.WithUnaryInterceptor(retry.RetryingUnaryClientInterceptor(retry.WithRetryOn(codes.Unavailable))),
-		grpc.WithStreamInterceptor(retry.RetryingStreamClientInterceptor(retry.WithRetryOn(codes.Unavailable))))
+	dialOpts := c.getSessionDialOptions(key.session)
+	if key.tenant != "" {
+		dialOpts = append(dialOpts, getTenantDialOptions(key.tenant)...)
+	}
+	driverConn, err = grpc.DialContext(ctx, fmt.Sprintf("%s:%d", response.Address.Host, response.Address.Port), dialOpts...)
 	if err != nil {
 		return nil, err
 	}
-	c.primitiveConns[primitive] = driverConn
+	c.primitiveConns[key] = driverConn
+	c.connKeys = append(c.connKeys, key)
 	return driverConn, nil
 }
 
+// getBrokerDialOptions returns the dial options for the broker connection
+func (c *atomixClient) getBrokerDialOptions() []grpc.DialOption {
+	opts := []grpc.DialOption{
+		grpc.WithInsecure(),
+		grpc.WithUnaryInterceptor(retry.RetryingUnaryClientInterceptor(retry.WithRetryOn(codes.Unavailable))),
+	}
+	if c.options.keepalive != nil {
+		opts = append(opts, grpc.WithKeepaliveParams(*c.options.keepalive))
+	}
+	if c.options.dialer != nil {
+		opts = append(opts, grpc.WithContextDialer(c.options.dialer))
+	}
+	if c.options.tenant != "" {
+		opts = append(opts, getTenantDialOptions(c.options.tenant)...)
+	}
+	return opts
+}
+
+// getPrimitiveDialOptions returns the dial options for primitive connections of the client's session
+func (c *atomixClient) getPrimitiveDialOptions() []grpc.DialOption {
+	return c.getSessionDialOptions(c.options.clientID)
+}
+
+// getSessionDialOptions returns the dial options for primitive connections of the given session
+func (c *atomixClient) getSessionDialOptions(sessionID string) []grpc.DialOption {
+	retryUnary := retry.RetryingUnaryClientInterceptor(retry.WithRetryOn(codes.Unavailable))
+	retryStream := retry.RetryingStreamClientInterceptor(retry.WithRetryOn(codes.Unavailable))
+	// Requests whose context is already done fail before reaching the other interceptors
+	preflightUnary, preflightStream := preflightInterceptors()
+	opts := []grpc.DialOption{
+		grpc.WithInsecure(),
+		grpc.WithChainUnaryInterceptor(preflightUnary),
+		grpc.WithChainStreamInterceptor(preflightStream),
+	}
+	// Response headers are captured once the operation has completed, including any retries
+	opts = append(opts, grpc.WithChainUnaryInterceptor(headerCaptureInterceptor()))
+	if c.slowOps != nil {
+		// Operations are timed as seen by the caller, including retries and queueing
+		slowUnary, slowStream := c.slowOps.interceptors()
+		opts = append(opts,
+			grpc.WithChainUnaryInterceptor(slowUnary),
+			grpc.WithChainStreamInterceptor(slowStream))
+	}
+	if c.retryBudget != nil {
+		// Each retry attempt made by the retrying interceptors draws from the budget
+		callUnary, callStream := c.retryBudget.callInterceptors()
+		attemptUnary, attemptStream := c.retryBudget.attemptInterceptors()
+		opts = append(opts,
+			grpc.WithChainUnaryInterceptor(callUnary, retryUnary, attemptUnary),
+			grpc.WithChainStreamInterceptor(callStream, retryStream, attemptStream))
+	} else {
+		opts = append(opts,
+			grpc.WithChainUnaryInterceptor(retryUnary),
+			grpc.WithChainStreamInterceptor(retryStream))
+	}
+	if c.inFlight != nil {
+		// Each attempt made by the retrying interceptors holds a slot of the session's limiter
+		limitUnary, limitStream := c.inFlight.get(sessionID).interceptors()
+		opts = append(opts,
+			grpc.WithChainUnaryInterceptor(limitUnary),
+			grpc.WithChainStreamInterceptor(limitStream))
+	}
+	// Streams are tracked so shutdown can wait for them to drain
+	opts = append(opts, grpc.WithChainStreamInterceptor(c.streams.interceptor()))
+	if c.options.keepalive != nil {
+		opts = append(opts, grpc.WithKeepaliveParams(*c.options.keepalive))
+	}
+	if c.options.dialer != nil {
+		opts = append(opts, grpc.WithContextDialer(c.options.dialer))
+	}
+	var callOpts []grpc.CallOption
+	if c.options.maxRecvMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(c.options.maxRecvMsgSize))
+	}
+	if c.options.maxSendMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(c.options.maxSendMsgSize))
+	}
+	if len(callOpts) > 0 {
+		opts = append(opts, grpc.WithDefaultCallOptions(callOpts...))
+	}
+	return opts
+}
+
 func newPrimitiveID(t primitive.Type, name string) primitiveapi.PrimitiveId {
 	return primitiveapi.PrimitiveId{
 		Type: t.String(),
@@ -165,78 +427,238 @@ func getPrimitiveOpts(clientOpts clientOptions, primitiveOpts ...primitive.Optio
 	return append([]primitive.Option{primitive.WithSessionID(clientOpts.clientID)}, primitiveOpts...)
 }
 
+// registration is the registration of a primitive created by the client
+type registration struct {
+	key       primitiveConnKey
+	primitive primitive.Primitive
+}
+
+// newRegistration creates a registration for a primitive, returning the options with which to create it
+// The options include a close hook that removes the registration once the primitive is closed, so primitives
+// closed by the caller are not retained until the client is closed.
+func (c *atomixClient) newRegistration(id primitiveapi.PrimitiveId, opts ...primitive.Option) (*registration, []primitive.Option) {
+	r := &registration{
+		key: c.getConnKey(id, opts...),
+	}
+	opts = append(getPrimitiveOpts(c.options, opts...), primitive.WithCloseHook(func() {
+		c.unregister(r)
+	}))
+	return r, opts
+}
+
+// register registers a primitive created by the client to be closed when the client is closed
+// Primitives of the same type and name share the client's session, so only the latest instance is retained.
+func (c *atomixClient) register(r *registration, p primitive.Primitive) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r.primitive = p
+	if _, ok := c.primitives[r.key]; !ok {
+		c.primitiveKeys = append(c.primitiveKeys, r.key)
+	}
+	c.primitives[r.key] = r
+}
+
+// unregister removes a closed primitive from the primitives to be closed when the client is closed
+func (c *atomixClient) unregister(r *registration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.primitives[r.key] != r {
+		return
+	}
+	delete(c.primitives, r.key)
+	for i, key := range c.primitiveKeys {
+		if key == r.key {
+			c.primitiveKeys = append(c.primitiveKeys[:i], c.primitiveKeys[i+1:]...)
+			break
+		}
+	}
+}
+
 func (c *atomixClient) GetCounter(ctx context.Context, name string, opts ...primitive.Option) (counter.Counter, error) {
-	conn, err := c.connect(ctx, newPrimitiveID(counter.Type, name))
+	id := newPrimitiveID(counter.Type, name)
+	conn, err := c.connect(ctx, id, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return counter.New(ctx, name, conn, getPrimitiveOpts(c.options, opts...)...)
+	r, primitiveOpts := c.newRegistration(id, opts...)
+	p, err := counter.New(ctx, name, conn, primitiveOpts...)
+	if err != nil {
+		return nil, err
+	}
+	c.register(r, p)
+	return p, nil
 }
 
 func (c *atomixClient) GetElection(ctx context.Context, name string, opts ...primitive.Option) (election.Election, error) {
-	conn, err := c.connect(ctx, newPrimitiveID(election.Type, name))
+	id := newPrimitiveID(election.Type, name)
+	conn, err := c.connect(ctx, id, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return election.New(ctx, name, conn, getPrimitiveOpts(c.options, opts...)...)
+	r, primitiveOpts := c.newRegistration(id, opts...)
+	p, err := election.New(ctx, name, conn, primitiveOpts...)
+	if err != nil {
+		return nil, err
+	}
+	c.register(r, p)
+	return p, nil
 }
 
 func (c *atomixClient) GetIndexedMap(ctx context.Context, name string, opts ...primitive.Option) (indexedmap.IndexedMap, error) {
-	conn, err := c.connect(ctx, newPrimitiveID(indexedmap.Type, name))
+	id := newPrimitiveID(indexedmap.Type, name)
+	conn, err := c.connect(ctx, id, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return indexedmap.New(ctx, name, conn, getPrimitiveOpts(c.options, opts...)...)
+	r, primitiveOpts := c.newRegistration(id, opts...)
+	p, err := indexedmap.New(ctx, name, conn, primitiveOpts...)
+	if err != nil {
+		return nil, err
+	}
+	c.register(r, p)
+	return p, nil
 }
 
 func (c *atomixClient) GetList(ctx context.Context, name string, opts ...primitive.Option) (list.List, error) {
-	conn, err := c.connect(ctx, newPrimitiveID(list.Type, name))
+	id := newPrimitiveID(list.Type, name)
+	conn, err := c.connect(ctx, id, opts...)
+	if err != nil {
+		return nil, err
+	}
+	r, primitiveOpts := c.newRegistration(id, opts...)
+	p, err := list.New(ctx, name, conn, primitiveOpts...)
 	if err != nil {
 		return nil, err
 	}
-	return list.New(ctx, name, conn, getPrimitiveOpts(c.options, opts...)...)
+	c.register(r, p)
+	return p, nil
 }
 
 func (c *atomixClient) GetLock(ctx context.Context, name string, opts ...primitive.Option) (lock.Lock, error) {
-	conn, err := c.connect(ctx, newPrimitiveID(lock.Type, name))
+	id := newPrimitiveID(lock.Type, name)
+	conn, err := c.connect(ctx, id, opts...)
+	if err != nil {
+		return nil, err
+	}
+	r, primitiveOpts := c.newRegistration(id, opts...)
+	p, err := lock.New(ctx, name, conn, primitiveOpts...)
 	if err != nil {
 		return nil, err
 	}
-	return lock.New(ctx, name, conn, getPrimitiveOpts(c.options, opts...)...)
+	c.register(r, p)
+	return p, nil
 }
 
 func (c *atomixClient) GetMap(ctx context.Context, name string, opts ...primitive.Option) (_map.Map, error) {
-	conn, err := c.connect(ctx, newPrimitiveID(_map.Type, name))
+	id := newPrimitiveID(_map.Type, name)
+	conn, err := c.connect(ctx, id, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return _map.New(ctx, name, conn, getPrimitiveOpts(c.options, opts...)...)
+	r, primitiveOpts := c.newRegistration(id, opts...)
+	p, err := _map.New(ctx, name, conn, primitiveOpts...)
+	if err != nil {
+		return nil, err
+	}
+	c.register(r, p)
+	return p, nil
 }
 
 func (c *atomixClient) GetSet(ctx context.Context, name string, opts ...primitive.Option) (set.Set, error) {
-	conn, err := c.connect(ctx, newPrimitiveID(set.Type, name))
+	id := newPrimitiveID(set.Type, name)
+	conn, err := c.connect(ctx, id, opts...)
+	if err != nil {
+		return nil, err
+	}
+	r, primitiveOpts := c.newRegistration(id, opts...)
+	p, err := set.New(ctx, name, conn, primitiveOpts...)
 	if err != nil {
 		return nil, err
 	}
-	return set.New(ctx, name, conn, getPrimitiveOpts(c.options, opts...)...)
+	c.register(r, p)
+	return p, nil
 }
 
 func (c *atomixClient) GetValue(ctx context.Context, name string, opts ...primitive.Option) (value.Value, error) {
-	conn, err := c.connect(ctx, newPrimitiveID(value.Type, name))
+	id := newPrimitiveID(value.Type, name)
+	conn, err := c.connect(ctx, id, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return value.New(ctx, name, conn, getPrimitiveOpts(c.options, opts...)...)
+	r, primitiveOpts := c.newRegistration(id, opts...)
+	p, err := value.New(ctx, name, conn, primitiveOpts...)
+	if err != nil {
+		return nil, err
+	}
+	c.register(r, p)
+	return p, nil
+}
+
+// isClosed returns whether the client has been closed
+func (c *atomixClient) isClosed() bool {
+	return atomic.LoadInt32(&c.closed) == 1
 }
 
+// Close closes the client
+// Shutdown is ordered: the sessions of the primitives created by the client are closed first, in the reverse
+// of the order in which the primitives were created, then the client waits up to the shutdown timeout for open
+// streams to drain before closing the primitive connections and finally the broker connection. All steps are
+// attempted even if earlier steps fail, and any errors are returned as CloseErrors. Once Close has been called
+// the client no longer opens connections, and subsequent calls to Close return nil.
 func (c *atomixClient) Close() error {
+	if !atomic.CompareAndSwapInt32(&c.closed, 0, 1) {
+		return nil
+	}
+
 	c.mu.Lock()
-	defer c.mu.Unlock()
-	for _, conn := range c.primitiveConns {
-		conn.Close()
+	primitives := make([]primitive.Primitive, 0, len(c.primitiveKeys))
+	for i := len(c.primitiveKeys) - 1; i >= 0; i-- {
+		primitives = append(primitives, c.primitives[c.primitiveKeys[i]].primitive)
+	}
+	c.primitives = make(map[primitiveConnKey]*registration)
+	c.primitiveKeys = nil
+	c.mu.Unlock()
+
+	var errs CloseErrors
+	ctx, cancel := context.WithTimeout(context.Background(), c.options.shutdownTimeout)
+	defer cancel()
+	for _, p := range primitives {
+		if err := p.Close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close %s %s: %w", p.Type(), p.Name(), err))
+		}
+	}
+
+	// The client lock is not held while waiting so the client remains usable until the streams drain
+	if err := c.streams.wait(ctx); err != nil {
+		log.Warnf("Closing connections with open streams: %v", err)
+	}
+
+	c.mu.Lock()
+	conns := make([]*grpc.ClientConn, 0, len(c.connKeys))
+	for _, key := range c.connKeys {
+		conns = append(conns, c.primitiveConns[key])
+	}
+	c.primitiveConns = make(map[primitiveConnKey]*grpc.ClientConn)
+	c.connKeys = nil
+	c.mu.Unlock()
+
+	for _, conn := range conns {
+		if err := conn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	c.brokerMu.Lock()
+	brokerConn := c.brokerConn
+	c.brokerConn = nil
+	c.brokerMu.Unlock()
+	if brokerConn != nil {
+		if err := brokerConn.Close(); err != nil {
+			errs = append(errs, err)
+		}
 	}
-	if c.brokerConn != nil {
-		return c.brokerConn.Close()
+	if len(errs) > 0 {
+		return errs
 	}
 	return nil
 }
@@ -0,0 +1,491 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package atomix
+
+import (
+	"context"
+	brokerapi "github.com/atomix/atomix-api/go/atomix/management/broker"
+	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	mapapi "github.com/atomix/atomix-api/go/atomix/primitive/map"
+	"github.com/atomix/atomix-go-client/pkg/atomix/counter"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
+	"github.com/atomix/atomix-go-client/pkg/atomix/lock"
+	_map "github.com/atomix/atomix-go-client/pkg/atomix/map"
+	"github.com/atomix/atomix-go-client/pkg/atomix/value"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testBroker is a broker that knows a fixed set of primitives
+type testBroker struct {
+	brokerapi.UnimplementedBrokerServer
+	primitives map[brokerapi.PrimitiveId]bool
+	port       int
+}
+
+func (b *testBroker) LookupPrimitive(ctx context.Context, request *brokerapi.LookupPrimitiveRequest) (*brokerapi.LookupPrimitiveResponse, error) {
+	if !b.primitives[request.PrimitiveID] {
+		return nil, status.Errorf(codes.NotFound, "primitive %s not found", request.PrimitiveID.Name)
+	}
+	port := b.port
+	if port == 0 {
+		port = 5679
+	}
+	return &brokerapi.LookupPrimitiveResponse{
+		Address: brokerapi.PrimitiveAddress{
+			Host: "127.0.0.1",
+			Port: int32(port),
+		},
+	}, nil
+}
+
+// startTestBroker starts a broker server, returning the port on which it is listening
+func startTestBroker(t *testing.T, broker brokerapi.BrokerServer) (int, func()) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := grpc.NewServer()
+	brokerapi.RegisterBrokerServer(server, broker)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	return lis.Addr().(*net.TCPAddr).Port, server.Stop
+}
+
+func TestExists(t *testing.T) {
+	broker := &testBroker{
+		primitives: map[brokerapi.PrimitiveId]bool{
+			{PrimitiveId: newPrimitiveID(_map.Type, "foo")}: true,
+		},
+	}
+	port, stop := startTestBroker(t, broker)
+	defer stop()
+
+	client := NewClient(WithBrokerHost("127.0.0.1"), WithBrokerPort(port))
+	defer client.Close()
+
+	exists, err := client.Exists(context.Background(), _map.Type, "foo")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = client.Exists(context.Background(), _map.Type, "bar")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	exists, err = client.Exists(context.Background(), counter.Type, "foo")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestExistsError(t *testing.T) {
+	port, stop := startTestBroker(t, &brokerapi.UnimplementedBrokerServer{})
+	defer stop()
+
+	client := NewClient(WithBrokerHost("127.0.0.1"), WithBrokerPort(port))
+	defer client.Close()
+
+	exists, err := client.Exists(context.Background(), _map.Type, "foo")
+	assert.Error(t, err)
+	assert.True(t, errors.IsNotSupported(err))
+	assert.False(t, exists)
+}
+
+// testMapServer is a map server that streams a single large entry
+type testMapServer struct {
+	mapapi.UnimplementedMapServiceServer
+	value []byte
+}
+
+func (s *testMapServer) Entries(request *mapapi.EntriesRequest, stream mapapi.MapService_EntriesServer) error {
+	return stream.Send(&mapapi.EntriesResponse{
+		Entry: mapapi.Entry{
+			Key: mapapi.Key{
+				Key: "foo",
+			},
+			Value: &mapapi.Value{
+				Value: s.value,
+			},
+		},
+	})
+}
+
+// readLargeEntry streams the entries of a map server using the dial options of the given client
+func readLargeEntry(t *testing.T, client *atomixClient, value []byte) ([]grpc.CallOption, *mapapi.EntriesResponse, error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := grpc.NewServer()
+	mapapi.RegisterMapServiceServer(server, &testMapServer{value: value})
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	defer server.Stop()
+
+	var callOpts []grpc.CallOption
+	interceptor := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		callOpts = opts
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+	opts := append(client.getPrimitiveDialOptions(), grpc.WithChainStreamInterceptor(interceptor))
+	conn, err := grpc.Dial(lis.Addr().String(), opts...)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	stream, err := mapapi.NewMapServiceClient(conn).Entries(context.Background(), &mapapi.EntriesRequest{})
+	assert.NoError(t, err)
+	response, err := stream.Recv()
+	return callOpts, response, err
+}
+
+func TestMaxMessageSizeOptions(t *testing.T) {
+	const size = 8 * 1024 * 1024
+	value := make([]byte, 5*1024*1024)
+
+	// Messages larger than the default maximum size cannot be received
+	_, _, err := readLargeEntry(t, NewClient().(*atomixClient), value)
+	assert.Error(t, err)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	client := NewClient(WithMaxRecvMsgSize(size), WithMaxSendMsgSize(size)).(*atomixClient)
+	callOpts, response, err := readLargeEntry(t, client, value)
+	assert.NoError(t, err)
+	assert.Len(t, response.Entry.Value.Value, len(value))
+
+	var recvSize, sendSize int
+	for _, opt := range callOpts {
+		switch o := opt.(type) {
+		case grpc.MaxRecvMsgSizeCallOption:
+			recvSize = o.MaxRecvMsgSize
+		case grpc.MaxSendMsgSizeCallOption:
+			sendSize = o.MaxSendMsgSize
+		}
+	}
+	assert.Equal(t, size, recvSize)
+	assert.Equal(t, size, sendSize)
+}
+
+func TestWarmup(t *testing.T) {
+	primitivePort, stopPrimitive := startTestBroker(t, &brokerapi.UnimplementedBrokerServer{})
+	defer stopPrimitive()
+
+	broker := &testBroker{
+		primitives: map[brokerapi.PrimitiveId]bool{
+			{PrimitiveId: newPrimitiveID(_map.Type, "foo")}: true,
+		},
+		port: primitivePort,
+	}
+	brokerPort, stopBroker := startTestBroker(t, broker)
+	defer stopBroker()
+
+	// Warmup connects to the broker
+	client := NewClient(WithBrokerHost("127.0.0.1"), WithBrokerPort(brokerPort)).(*atomixClient)
+	assert.NoError(t, client.Warmup(context.Background()))
+	assert.Equal(t, connectivity.Ready, client.brokerConn.GetState())
+	assert.NoError(t, client.Close())
+
+	// Warmup waits for primitive connections to become ready
+	client = NewClient(WithBrokerHost("127.0.0.1"), WithBrokerPort(brokerPort)).(*atomixClient)
+	conn, err := client.connect(context.Background(), newPrimitiveID(_map.Type, "foo"))
+	assert.NoError(t, err)
+	assert.NoError(t, client.Warmup(context.Background()))
+	assert.Equal(t, connectivity.Ready, client.brokerConn.GetState())
+	assert.Equal(t, connectivity.Ready, conn.GetState())
+
+	// Warmup fails on a closed client
+	assert.NoError(t, client.Close())
+	assert.True(t, errors.IsClosed(client.Warmup(context.Background())))
+}
+
+func TestWarmupTimeout(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	port := lis.Addr().(*net.TCPAddr).Port
+	assert.NoError(t, lis.Close())
+
+	// Warmup honors the context deadline if the broker cannot be reached
+	client := NewClient(WithBrokerHost("127.0.0.1"), WithBrokerPort(port))
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err = client.Warmup(ctx)
+	assert.True(t, errors.IsTimeout(err))
+}
+
+// testPrimitiveServer is a primitive server that records the primitives closed by clients
+type testPrimitiveServer struct {
+	primitiveapi.UnimplementedPrimitiveServer
+	failures map[string]bool
+	closed   []string
+	mu       sync.Mutex
+}
+
+func (s *testPrimitiveServer) Create(ctx context.Context, request *primitiveapi.CreateRequest) (*primitiveapi.CreateResponse, error) {
+	return &primitiveapi.CreateResponse{}, nil
+}
+
+func (s *testPrimitiveServer) Close(ctx context.Context, request *primitiveapi.CloseRequest) (*primitiveapi.CloseResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = append(s.closed, request.Headers.PrimitiveID.Name)
+	if s.failures[request.Headers.PrimitiveID.Name] {
+		return nil, status.Error(codes.Internal, "close failed")
+	}
+	return &primitiveapi.CloseResponse{}, nil
+}
+
+func TestCloseOrder(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	primitiveServer := &testPrimitiveServer{failures: map[string]bool{"bar": true}}
+	server := grpc.NewServer()
+	primitiveapi.RegisterPrimitiveServer(server, primitiveServer)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	defer server.Stop()
+
+	broker := &testBroker{
+		primitives: map[brokerapi.PrimitiveId]bool{
+			{PrimitiveId: newPrimitiveID(counter.Type, "foo")}: true,
+			{PrimitiveId: newPrimitiveID(lock.Type, "bar")}:    true,
+			{PrimitiveId: newPrimitiveID(value.Type, "baz")}:   true,
+		},
+		port: lis.Addr().(*net.TCPAddr).Port,
+	}
+	brokerPort, stopBroker := startTestBroker(t, broker)
+	defer stopBroker()
+
+	client := NewClient(WithBrokerHost("127.0.0.1"), WithBrokerPort(brokerPort))
+	_, err = client.GetCounter(context.Background(), "foo")
+	assert.NoError(t, err)
+	_, err = client.GetLock(context.Background(), "bar")
+	assert.NoError(t, err)
+	v, err := client.GetValue(context.Background(), "baz")
+	assert.NoError(t, err)
+
+	// Primitives closed by the caller are dropped from the client and not closed again
+	assert.NoError(t, v.Close(context.Background()))
+	assert.Len(t, client.(*atomixClient).primitives, 2)
+	assert.Len(t, client.(*atomixClient).primitiveKeys, 2)
+
+	// Sessions are closed in reverse order of creation while the connections are still open, and a failure
+	// to close one session does not prevent the others from being closed
+	err = client.Close()
+	assert.Error(t, err)
+	var closeErrs CloseErrors
+	assert.True(t, errors.As(err, &closeErrs))
+	assert.Len(t, closeErrs, 1)
+	assert.Contains(t, closeErrs.Error(), "Lock bar")
+	assert.Equal(t, []string{"baz", "bar", "foo"}, primitiveServer.closed)
+
+	// The connections are closed once the sessions have been closed
+	_, err = client.GetCounter(context.Background(), "foo")
+	assert.True(t, errors.IsClosed(err))
+	assert.Len(t, client.(*atomixClient).primitiveConns, 0)
+	assert.Nil(t, client.(*atomixClient).brokerConn)
+
+	// Closing a closed client has no effect
+	assert.NoError(t, client.Close())
+	assert.Equal(t, []string{"baz", "bar", "foo"}, primitiveServer.closed)
+}
+
+// testWatchMapServer is a map server whose watch streams stay open until they are cancelled
+type testWatchMapServer struct {
+	mapapi.UnimplementedMapServiceServer
+}
+
+func (s *testWatchMapServer) Events(request *mapapi.EventsRequest, stream mapapi.MapService_EventsServer) error {
+	if err := stream.Send(&mapapi.EventsResponse{}); err != nil {
+		return err
+	}
+	<-stream.Context().Done()
+	return nil
+}
+
+func TestCloseWithOpenWatch(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := grpc.NewServer()
+	primitiveapi.RegisterPrimitiveServer(server, &testPrimitiveServer{})
+	mapapi.RegisterMapServiceServer(server, &testWatchMapServer{})
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	defer server.Stop()
+
+	broker := &testBroker{
+		primitives: map[brokerapi.PrimitiveId]bool{
+			{PrimitiveId: newPrimitiveID(_map.Type, "foo")}: true,
+		},
+		port: lis.Addr().(*net.TCPAddr).Port,
+	}
+	brokerPort, stopBroker := startTestBroker(t, broker)
+	defer stopBroker()
+
+	client := NewClient(WithBrokerHost("127.0.0.1"), WithBrokerPort(brokerPort), WithShutdownTimeout(5*time.Second))
+	m, err := client.GetMap(context.Background(), "foo")
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan _map.Event)
+	assert.NoError(t, m.Watch(ctx, ch))
+
+	closed := make(chan error)
+	go func() {
+		closed <- client.Close()
+	}()
+
+	// The client is not locked while Close waits for the watch to end
+	broadcast := make(chan []error)
+	go func() {
+		broadcast <- client.Broadcast(context.Background(), func(conn *grpc.ClientConn) error {
+			return nil
+		})
+	}()
+	select {
+	case errs := <-broadcast:
+		assert.Len(t, errs, 1)
+	case <-time.After(time.Second):
+		t.Fatal("Broadcast blocked while Close waited for streams")
+	}
+	select {
+	case <-closed:
+		t.Fatal("Close returned with an open watch")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// Close returns once the watch ends
+	cancel()
+	select {
+	case err := <-closed:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Close did not return once the watch ended")
+	}
+	for range ch {
+	}
+	assert.NoError(t, client.Close())
+}
+
+func TestStreamTracker(t *testing.T) {
+	tracker := newStreamTracker()
+	assert.NoError(t, tracker.wait(context.Background()))
+
+	tracker.add()
+	tracker.add()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, tracker.wait(ctx))
+
+	drained := make(chan error)
+	go func() {
+		drained <- tracker.wait(context.Background())
+	}()
+	tracker.done()
+	select {
+	case <-drained:
+		t.Fatal("streams drained while a stream is open")
+	case <-time.After(10 * time.Millisecond):
+	}
+	tracker.done()
+	select {
+	case err := <-drained:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("streams were not drained")
+	}
+}
+
+// testClientStream is a client stream that fails to receive once its context is done
+type testClientStream struct {
+	grpc.ClientStream
+	ctx context.Context
+}
+
+func (s *testClientStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamTrackerContext(t *testing.T) {
+	tracker := newStreamTracker()
+	interceptor := tracker.interceptor()
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return &testClientStream{ctx: ctx}, nil
+	}
+
+	// A stream abandoned by cancelling its context without receiving from it is released
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := interceptor(ctx, &grpc.StreamDesc{}, nil, "test", streamer)
+	assert.NoError(t, err)
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer waitCancel()
+	assert.Error(t, tracker.wait(waitCtx))
+
+	cancel()
+	waitCtx, waitCancel = context.WithTimeout(context.Background(), time.Second)
+	defer waitCancel()
+	assert.NoError(t, tracker.wait(waitCtx))
+}
+
+func TestBroadcast(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := grpc.NewServer()
+	primitiveapi.RegisterPrimitiveServer(server, &testPrimitiveServer{})
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	defer server.Stop()
+
+	broker := &testBroker{
+		primitives: map[brokerapi.PrimitiveId]bool{
+			{PrimitiveId: newPrimitiveID(counter.Type, "foo")}: true,
+			{PrimitiveId: newPrimitiveID(lock.Type, "bar")}:    true,
+			{PrimitiveId: newPrimitiveID(value.Type, "baz")}:   true,
+		},
+		port: lis.Addr().(*net.TCPAddr).Port,
+	}
+	brokerPort, stopBroker := startTestBroker(t, broker)
+	defer stopBroker()
+
+	client := NewClient(WithBrokerHost("127.0.0.1"), WithBrokerPort(brokerPort)).(*atomixClient)
+	defer client.Close()
+
+	// Broadcasting without connections invokes nothing
+	assert.Len(t, client.Broadcast(context.Background(), func(conn *grpc.ClientConn) error {
+		return nil
+	}), 0)
+
+	_, err = client.GetCounter(context.Background(), "foo")
+	assert.NoError(t, err)
+	_, err = client.GetLock(context.Background(), "bar")
+	assert.NoError(t, err)
+	_, err = client.GetValue(context.Background(), "baz")
+	assert.NoError(t, err)
+
+	// Every connection is invoked and errors are collected in the order the connections were opened
+	failed := client.primitiveConns[client.getConnKey(newPrimitiveID(lock.Type, "bar"))]
+	invoked := make(map[*grpc.ClientConn]bool)
+	mu := &sync.Mutex{}
+	errs := client.Broadcast(context.Background(), func(conn *grpc.ClientConn) error {
+		mu.Lock()
+		invoked[conn] = true
+		mu.Unlock()
+		if conn == failed {
+			return errors.NewUnavailable("unavailable")
+		}
+		return nil
+	})
+	assert.Len(t, invoked, 3)
+	assert.Len(t, errs, 3)
+	assert.NoError(t, errs[0])
+	assert.True(t, errors.IsUnavailable(errs[1]))
+	assert.NoError(t, errs[2])
+}
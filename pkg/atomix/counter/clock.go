@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package counter
+
+import (
+	"context"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
+)
+
+// Clock provides a distributed logical clock backed by a Counter
+// Every call to Now atomically increments the underlying counter, so each timestamp is globally unique
+// and strictly greater than any timestamp previously returned by any clock backed by the same counter.
+// Because each timestamp requires a write to the counter, the throughput of a clock is bounded by the
+// write throughput of the counter's partition; timestamps should not be requested on hot paths.
+type Clock interface {
+	// Now returns a new unique timestamp
+	Now(ctx context.Context) (uint64, error)
+}
+
+// NewClock returns a new Clock backed by the given Counter
+// The counter should be dedicated to the clock, as setting or decrementing the counter breaks
+// the monotonicity of the clock.
+func NewClock(counter Counter) Clock {
+	return &clock{
+		counter: counter,
+	}
+}
+
+// clock is the default implementation of Clock
+type clock struct {
+	counter Counter
+}
+
+func (c *clock) Now(ctx context.Context) (uint64, error) {
+	value, err := c.counter.Increment(ctx, 1)
+	if err != nil {
+		return 0, err
+	}
+	if value <= 0 {
+		return 0, errors.NewConflict("counter %s has been set to a non-positive value", c.counter.Name())
+	}
+	return uint64(value), nil
+}
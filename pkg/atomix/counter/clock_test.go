@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package counter
+
+import (
+	"context"
+	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	"github.com/atomix/atomix-go-client/pkg/atomix/util/test"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func TestClock(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestClock",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn1, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	conn2, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	counter1, err := New(context.TODO(), "TestClock", conn1)
+	assert.NoError(t, err)
+
+	counter2, err := New(context.TODO(), "TestClock", conn2)
+	assert.NoError(t, err)
+
+	clocks := []Clock{NewClock(counter1), NewClock(counter2)}
+
+	const callers = 4
+	const calls = 25
+
+	wg := &sync.WaitGroup{}
+	timestamps := make([][]uint64, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			clock := clocks[i%len(clocks)]
+			for j := 0; j < calls; j++ {
+				timestamp, err := clock.Now(context.TODO())
+				assert.NoError(t, err)
+				timestamps[i] = append(timestamps[i], timestamp)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	unique := make(map[uint64]bool)
+	for _, caller := range timestamps {
+		for j := 1; j < len(caller); j++ {
+			assert.Greater(t, caller[j], caller[j-1])
+		}
+		for _, timestamp := range caller {
+			assert.False(t, unique[timestamp])
+			unique[timestamp] = true
+		}
+	}
+	assert.Len(t, unique, callers*calls)
+
+	timestamp, err := clocks[0].Now(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(callers*calls+1), timestamp)
+
+	assert.NoError(t, counter1.Set(context.TODO(), -10))
+	_, err = clocks[0].Now(context.TODO())
+	assert.Error(t, err)
+
+	assert.NoError(t, counter1.Close(context.Background()))
+	assert.NoError(t, counter2.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
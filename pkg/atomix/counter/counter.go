@@ -7,8 +7,8 @@ package counter
 import (
 	"context"
 	api "github.com/atomix/atomix-api/go/atomix/primitive/counter"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
-	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
 	"google.golang.org/grpc"
 )
 
@@ -29,7 +29,7 @@ type Counter interface {
 	Get(ctx context.Context) (int64, error)
 
 	// Set sets the value of the counter
-	Set(ctx context.Context, value int64) error
+	Set(ctx context.Context, value int64, opts ...SetOption) error
 
 	// Increment increments the counter by the given delta
 	Increment(ctx context.Context, delta int64) (int64, error)
@@ -75,15 +75,21 @@ func (c *counter) Get(ctx context.Context) (int64, error) {
 	return response.Value, nil
 }
 
-func (c *counter) Set(ctx context.Context, value int64) error {
+func (c *counter) Set(ctx context.Context, value int64, opts ...SetOption) error {
 	request := &api.SetRequest{
 		Headers: c.GetHeaders(),
 		Value:   value,
 	}
-	_, err := c.client.Set(ctx, request)
+	for i := range opts {
+		opts[i].beforeSet(request)
+	}
+	response, err := c.client.Set(ctx, request)
 	if err != nil {
 		return errors.From(err)
 	}
+	for i := range opts {
+		opts[i].afterSet(response)
+	}
 	return nil
 }
 
@@ -7,6 +7,7 @@ package counter
 import (
 	"context"
 	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-client/pkg/atomix/util/test"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
 	"github.com/stretchr/testify/assert"
@@ -84,3 +85,51 @@ func TestCounterOperations(t *testing.T) {
 
 	assert.NoError(t, test.Stop())
 }
+
+func TestCounterSetIfValue(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestCounterSetIfValue",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	counter, err := New(context.TODO(), "TestCounterSetIfValue", conn)
+	assert.NoError(t, err)
+
+	// An unconditional set is always applied
+	err = counter.Set(context.TODO(), 5)
+	assert.NoError(t, err)
+
+	value, err := counter.Get(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), value)
+
+	// A guarded set is applied if the counter has not changed
+	err = counter.Set(context.TODO(), 10, IfValue(value))
+	assert.NoError(t, err)
+
+	value, err = counter.Get(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), value)
+
+	// A guarded set fails if the counter has changed
+	_, err = counter.Increment(context.TODO(), 1)
+	assert.NoError(t, err)
+
+	err = counter.Set(context.TODO(), 20, IfValue(value))
+	assert.Error(t, err)
+	assert.True(t, errors.IsConflict(err))
+
+	value, err = counter.Get(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), value)
+
+	assert.NoError(t, counter.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
@@ -5,6 +5,7 @@
 package counter
 
 import (
+	api "github.com/atomix/atomix-api/go/atomix/primitive/counter"
 	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
 )
 
@@ -16,3 +17,32 @@ type Option interface {
 
 // newCounterOptions is counter options
 type newCounterOptions struct{}
+
+// SetOption is an option for Set calls
+type SetOption interface {
+	beforeSet(request *api.SetRequest)
+	afterSet(response *api.SetResponse)
+}
+
+// IfValue sets the value the counter must have for a Set to be applied
+// Counters are not versioned, so the value observed by a prior read serves as the counter's version: if the
+// counter has changed since it was read, the Set fails with a Conflict error.
+func IfValue(value int64) SetOption {
+	return valueOption{value: value}
+}
+
+type valueOption struct {
+	value int64
+}
+
+func (o valueOption) beforeSet(request *api.SetRequest) {
+	request.Preconditions = append(request.Preconditions, api.Precondition{
+		Precondition: &api.Precondition_Value{
+			Value: o.value,
+		},
+	})
+}
+
+func (o valueOption) afterSet(response *api.SetResponse) {
+
+}
@@ -7,12 +7,14 @@ package election
 import (
 	"context"
 	api "github.com/atomix/atomix-api/go/atomix/primitive/election"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
-	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
+	metatime "github.com/atomix/atomix-go-framework/pkg/atomix/time"
 	"google.golang.org/grpc"
 	"io"
+	"time"
 )
 
 var log = logging.GetLogger("atomix", "client", "election")
@@ -36,11 +38,30 @@ type Election interface {
 	// GetTerm gets the current election term
 	GetTerm(ctx context.Context) (*Term, error)
 
+	// Candidates gets the IDs of the candidates in the current term in order of priority
+	// The leader, if any, is first, followed by the remaining candidates in the order in which they will be
+	// elected. If the election has no candidates, an empty list is returned.
+	Candidates(ctx context.Context) ([]string, error)
+
+	// GetPriorities gets the priority of each candidate in the current term, keyed by candidate ID
+	// Priorities are zero-based ranks in the order in which candidates will be elected, with the leader at
+	// priority 0. The server does not report priorities, so they are derived from the order of the term's
+	// candidates. If the election has no candidates, an empty map is returned.
+	GetPriorities(ctx context.Context) (map[string]int, error)
+
 	// Enter enters the instance into the election
+	// Enter is idempotent: the instance is entered under its session ID, and the server does not add a candidate
+	// that is already in the election, so Enter can be safely retried after an ambiguous failure, e.g. a timeout.
+	// An instance that is already a candidate keeps its priority.
 	Enter(ctx context.Context) (*Term, error)
 
 	// Leave removes the instance from the election
-	Leave(ctx context.Context) (*Term, error)
+	// If the WithGracePeriod option is provided and the instance is the leader, leadership is first handed off
+	// to the next candidate, and the instance withdraws from the election once the grace period has elapsed.
+	// If the context is done before the grace period elapses, the instance remains a candidate. If the
+	// WithRequireSuccessor option is provided, the instance refuses to leave while it is the leader and
+	// there are no other candidates to succeed it.
+	Leave(ctx context.Context, opts ...LeaveOption) (*Term, error)
 
 	// Anoint assigns leadership to the instance with the given ID
 	Anoint(ctx context.Context, id string) (*Term, error)
@@ -51,8 +72,19 @@ type Election interface {
 	// Evict removes the instance with the given ID from the election
 	Evict(ctx context.Context, id string) (*Term, error)
 
+	// TransferLeadership transfers leadership to the candidate with the given ID
+	// The target is anointed and the resulting term is verified to reflect the new leader. If the
+	// WithEvictLeader option is provided, the previous leader is then evicted from the election. A NotFound
+	// error is returned if the target is not a candidate, and a Conflict error is returned if the resulting
+	// term does not reflect the new leader.
+	TransferLeadership(ctx context.Context, id string, opts ...TransferOption) (*Term, error)
+
 	// Watch watches the election for changes
-	Watch(ctx context.Context, ch chan<- Event) error
+	// This is a non-blocking method. If the method returns without error, election events will be pushed onto
+	// the given channel, and the channel will be closed once the watch is closed. If the watch cannot be
+	// opened, an error is returned and the channel is not closed. If the WithKeepOpenOnError option is provided,
+	// the channel is kept open across recoverable stream failures.
+	Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption) error
 }
 
 // newTerm returns a new term from the response term
@@ -79,12 +111,126 @@ type Term struct {
 	Candidates []string
 }
 
+// isCandidate returns whether the instance with the given ID is a candidate in the term
+func (t *Term) isCandidate(id string) bool {
+	for _, candidate := range t.Candidates {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ranked returns the IDs of the candidates in the term in order of priority, with the leader first
+func (t *Term) ranked() []string {
+	candidates := make([]string, 0, len(t.Candidates))
+	if t.Leader != "" {
+		candidates = append(candidates, t.Leader)
+	}
+	for _, candidate := range t.Candidates {
+		if candidate != t.Leader {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
+}
+
+// RankChange is a change in the rank of a candidate between two terms
+// Ranks are zero-based positions in the priority order of the term, with the leader at rank 0. A rank of -1
+// indicates the candidate was not participating in the election.
+type RankChange struct {
+	// ID is the candidate ID
+	ID string
+
+	// PreviousRank is the rank of the candidate in the previous term
+	PreviousRank int
+
+	// Rank is the rank of the candidate in the current term
+	Rank int
+}
+
+// Gained returns whether the candidate gained rank
+func (c RankChange) Gained() bool {
+	return c.Rank != -1 && (c.PreviousRank == -1 || c.Rank < c.PreviousRank)
+}
+
+// Lost returns whether the candidate lost rank
+func (c RankChange) Lost() bool {
+	return c.PreviousRank != -1 && (c.Rank == -1 || c.Rank > c.PreviousRank)
+}
+
+// RankChanges returns the changes in the ranks of candidates from the previous term to the current term
+// Only candidates whose rank changed are returned. Candidates in the current term are listed first in
+// order of rank, followed by candidates that left the election in their previous order.
+func RankChanges(prev, curr Term) []RankChange {
+	prevRanks := make(map[string]int)
+	for rank, id := range prev.ranked() {
+		prevRanks[id] = rank
+	}
+	currRanks := make(map[string]int)
+	var changes []RankChange
+	for rank, id := range curr.ranked() {
+		currRanks[id] = rank
+		prevRank, ok := prevRanks[id]
+		if !ok {
+			prevRank = -1
+		}
+		if prevRank != rank {
+			changes = append(changes, RankChange{ID: id, PreviousRank: prevRank, Rank: rank})
+		}
+	}
+	for rank, id := range prev.ranked() {
+		if _, ok := currRanks[id]; !ok {
+			changes = append(changes, RankChange{ID: id, PreviousRank: rank, Rank: -1})
+		}
+	}
+	return changes
+}
+
 // EventType is the type of an Election event
 type EventType string
 
 const (
 	// EventChange indicates the election term changed
 	EventChange EventType = "change"
+
+	// EventError indicates the watch stream failed and is being recovered
+	// Error events are delivered only to watches opened with the WithKeepOpenOnError option.
+	EventError EventType = "error"
+)
+
+// WatchState is the state of an election watch
+type WatchState string
+
+const (
+	// WatchConnecting indicates the watch stream is being opened
+	WatchConnecting WatchState = "connecting"
+
+	// WatchOpen indicates the watch stream has been opened
+	WatchOpen WatchState = "open"
+
+	// WatchReconnecting indicates the watch stream is being reopened
+	// The stream is reopened when an attempt to open it fails and WithHandshakeRetry allows another attempt,
+	// and when an open stream fails with a reconnectable error and the watch was opened with
+	// WithKeepOpenOnError. Without WithKeepOpenOnError, an open stream that fails is closed, not reopened.
+	WatchReconnecting WatchState = "reconnecting"
+
+	// WatchClosed indicates the watch stream has been closed
+	WatchClosed WatchState = "closed"
+)
+
+// CloseReason is the reason an election watch was closed
+type CloseReason string
+
+const (
+	// CloseCanceled indicates the watch was closed because its context was cancelled or timed out
+	CloseCanceled CloseReason = "canceled"
+
+	// CloseEOF indicates the watch was closed because the server ended the watch stream
+	CloseEOF CloseReason = "eof"
+
+	// CloseError indicates the watch was closed because the watch stream failed
+	CloseError CloseReason = "error"
 )
 
 // Event is an election event
@@ -94,6 +240,22 @@ type Event struct {
 
 	// Term is the term that occurs as a result of the election event
 	Term Term
+
+	// Timestamp is the server time at which the term changed, as read by primitive.GetTimestamp
+	Timestamp metatime.Timestamp
+
+	// RankChanges is the changes in the ranks of candidates since the previous term received by the watch
+	// RankChanges is nil for the first event received by a watch.
+	RankChanges []RankChange
+
+	// PreviousLeader is the leader in the previous term received by the watch
+	// PreviousLeader is empty for the first event received by a watch and if the previous term had no
+	// leader. If leadership did not change, PreviousLeader is the current leader.
+	PreviousLeader string
+
+	// Err is the error with which the watch stream failed
+	// Err is set only for EventError events; the Term of an error event is empty.
+	Err error
 }
 
 // New creates a new election primitive
@@ -137,6 +299,26 @@ func (e *election) GetTerm(ctx context.Context) (*Term, error) {
 	return newTerm(&response.Term), nil
 }
 
+func (e *election) Candidates(ctx context.Context) ([]string, error) {
+	term, err := e.GetTerm(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return term.ranked(), nil
+}
+
+func (e *election) GetPriorities(ctx context.Context) (map[string]int, error) {
+	term, err := e.GetTerm(ctx)
+	if err != nil {
+		return nil, err
+	}
+	priorities := make(map[string]int)
+	for rank, candidate := range term.ranked() {
+		priorities[candidate] = rank
+	}
+	return priorities, nil
+}
+
 func (e *election) Enter(ctx context.Context) (*Term, error) {
 	request := &api.EnterRequest{
 		Headers:     e.GetHeaders(),
@@ -149,7 +331,54 @@ func (e *election) Enter(ctx context.Context) (*Term, error) {
 	return newTerm(&response.Term), nil
 }
 
-func (e *election) Leave(ctx context.Context) (*Term, error) {
+// hasSuccessor returns whether the given term has a candidate other than the instance with the given ID
+func hasSuccessor(term *Term, id string) bool {
+	for _, candidate := range term.Candidates {
+		if candidate != id {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *election) Leave(ctx context.Context, opts ...LeaveOption) (*Term, error) {
+	options := leaveOptions{}
+	for _, opt := range opts {
+		opt.applyLeave(&options)
+	}
+
+	if options.requireSuccessor {
+		term, err := e.GetTerm(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if term.Leader == e.ID() && !hasSuccessor(term, e.ID()) {
+			return nil, errors.NewConflict("%s is the leader of election %s and has no successor", e.ID(), e.Name())
+		}
+	}
+
+	if options.grace > 0 {
+		term, err := e.GetTerm(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if term.Leader == e.ID() {
+			for _, candidate := range term.Candidates {
+				if candidate != e.ID() {
+					if _, err := e.Anoint(ctx, candidate); err != nil {
+						return nil, err
+					}
+					break
+				}
+			}
+		}
+		select {
+		case <-time.After(options.grace):
+		case <-ctx.Done():
+			return nil, errors.From(ctx.Err())
+		}
+	}
+
 	request := &api.WithdrawRequest{
 		Headers:     e.GetHeaders(),
 		CandidateID: e.SessionID(),
@@ -197,57 +426,243 @@ func (e *election) Evict(ctx context.Context, id string) (*Term, error) {
 	return newTerm(&response.Term), nil
 }
 
-func (e *election) Watch(ctx context.Context, ch chan<- Event) error {
+func (e *election) TransferLeadership(ctx context.Context, id string, opts ...TransferOption) (*Term, error) {
+	options := transferOptions{}
+	for _, opt := range opts {
+		opt.applyTransfer(&options)
+	}
+
+	term, err := e.GetTerm(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !term.isCandidate(id) {
+		return nil, errors.NewNotFound("%s is not a candidate in election %s", id, e.Name())
+	}
+
+	leader := term.Leader
+	if leader != id {
+		term, err = e.Anoint(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if term.Leader != id {
+			return nil, errors.NewConflict("failed to transfer leadership to %s: %s is the leader", id, term.Leader)
+		}
+	}
+
+	if options.evict && leader != "" && leader != id {
+		term, err = e.Evict(ctx, leader)
+		if err != nil {
+			return nil, err
+		}
+		if term.Leader != id {
+			return nil, errors.NewConflict("lost leadership after evicting %s: %s is the leader", leader, term.Leader)
+		}
+	}
+	return term, nil
+}
+
+func (e *election) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption) error {
+	options := watchOptions{
+		attempts:       1,
+		invokeCallback: e.InvokeCallback,
+		reconnectable:  newCodeSet(defaultReconnectableCodes),
+	}
+	for _, opt := range opts {
+		opt.applyWatch(&options)
+	}
+
+	options.reconnects = &reconnectDebouncer{window: options.reconnectDebounce}
+	options.notify(WatchConnecting)
+	backoff := options.backoff
+	for attempt := 1; ; attempt++ {
+		timedOut, err := e.watch(ctx, ch, options, nil)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			options.notify(WatchClosed)
+			return errors.From(ctx.Err())
+		}
+		if !timedOut && !options.isReconnectable(err) {
+			options.notify(WatchClosed)
+			return err
+		}
+		if attempt >= options.attempts {
+			options.notify(WatchClosed)
+			if options.attempts > 1 {
+				return errors.New(errors.TypeOf(err), "watch failed after %d attempts: %v", attempt, err)
+			}
+			return err
+		}
+		log.Warnf("Watch attempt %d failed: %v", attempt, err)
+		options.notifyReconnect()
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			options.notify(WatchClosed)
+			return errors.From(ctx.Err())
+		}
+		backoff *= 2
+	}
+}
+
+// watch makes a single attempt to open a watch stream delivering events to the given channel
+// The previous term, if any, is the last term delivered on the channel before the watch was recovered. If the
+// attempt fails because the handshake timed out, the returned bool is true.
+func (e *election) watch(ctx context.Context, ch chan<- Event, options watchOptions, prev *Term) (bool, error) {
 	request := &api.EventsRequest{
 		Headers: e.GetHeaders(),
 	}
-	stream, err := e.client.Events(ctx, request)
+	streamCtx, cancel := context.WithCancel(ctx)
+	stream, err := e.client.Events(streamCtx, request)
 	if err != nil {
-		return errors.From(err)
+		cancel()
+		return false, errors.From(err)
 	}
 
-	openCh := make(chan struct{})
+	handshake := primitive.NewHandshake()
+	if options.waitGroup != nil {
+		options.waitGroup.Add(1)
+	}
 	go func() {
-		defer close(ch)
+		if options.waitGroup != nil {
+			defer options.waitGroup.Done()
+		}
+		defer cancel()
 		open := false
+		var reason CloseReason
+		var closeErr error
 		defer func() {
-			if !open {
-				close(openCh)
+			if open {
+				options.notify(WatchClosed)
+				options.notifyClose(reason, closeErr)
+				close(ch)
 			}
 		}()
 		for {
 			response, err := stream.Recv()
 			if err != nil {
+				if !open {
+					if err == io.EOF {
+						err = errors.NewUnavailable("watch stream closed before it was opened")
+					}
+					handshake.Fail(errors.From(err))
+					return
+				}
 				if err == io.EOF {
+					reason = CloseEOF
 					return
 				}
 				err = errors.From(err)
 				if errors.IsCanceled(err) || errors.IsTimeout(err) {
+					reason = CloseCanceled
 					return
 				}
 				log.Errorf("Watch failed: %v", err)
+				if options.keepOpen && options.isReconnectable(err) {
+					// The channel is handed off to the recovered watch
+					recoverErr := e.recoverWatch(ctx, ch, options, prev, err)
+					if recoverErr == nil {
+						open = false
+						return
+					}
+					if ctx.Err() != nil {
+						reason = CloseCanceled
+						return
+					}
+					err = recoverErr
+				}
+				reason, closeErr = CloseError, err
 				return
 			}
 
 			if !open {
-				close(openCh)
+				// If the handshake has already failed, the stream has been abandoned
+				if !handshake.Open() {
+					return
+				}
 				open = true
+				options.notify(WatchOpen)
 			}
+			if response.Event.Type == api.Event_NONE {
+				continue
+			}
+			timestamp := primitive.GetTimestamp(response.Headers)
 
 			switch response.Event.Type {
 			case api.Event_CHANGED:
+				term := *newTerm(&response.Event.Term)
+				var changes []RankChange
+				var prevLeader string
+				if prev != nil {
+					changes = RankChanges(*prev, term)
+					prevLeader = prev.Leader
+				}
+				prev = &term
 				ch <- Event{
-					Type: EventChange,
-					Term: *newTerm(&response.Event.Term),
+					Type:           EventChange,
+					Term:           term,
+					Timestamp:      timestamp,
+					RankChanges:    changes,
+					PreviousLeader: prevLeader,
 				}
 			}
 		}
 	}()
 
+	waitCtx := ctx
+	if options.handshakeTimeout > 0 {
+		var waitCancel context.CancelFunc
+		waitCtx, waitCancel = context.WithTimeout(ctx, options.handshakeTimeout)
+		defer waitCancel()
+	}
+	if err := handshake.Wait(waitCtx); err != nil {
+		// The stream may have been opened concurrently with the timeout
+		if handshake.Fail(errors.From(err)) {
+			cancel()
+			return ctx.Err() == nil && waitCtx.Err() != nil, errors.From(err)
+		}
+	}
+	return false, nil
+}
+
+// recoverWatch delivers an error event for a failed watch stream and reopens the watch on the same channel
+// Attempts to reopen the stream are retried with backoff while they fail with a reconnectable error. If the
+// watch cannot be reopened, the error is returned and the caller remains responsible for closing the channel.
+func (e *election) recoverWatch(ctx context.Context, ch chan<- Event, options watchOptions, prev *Term, cause error) error {
 	select {
-	case <-openCh:
-		return nil
+	case ch <- Event{Type: EventError, Err: cause}:
 	case <-ctx.Done():
-		return ctx.Err()
+		return errors.From(ctx.Err())
+	}
+
+	backoff := options.backoff
+	if backoff <= 0 {
+		backoff = defaultRecoveryBackoff
+	}
+	for {
+		options.notifyReconnect()
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return errors.From(ctx.Err())
+		}
+		timedOut, err := e.watch(ctx, ch, options, prev)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return errors.From(ctx.Err())
+		}
+		if !timedOut && !options.isReconnectable(err) {
+			return err
+		}
+		log.Warnf("Watch recovery failed: %v", err)
+		backoff *= 2
+		if backoff > maxRecoveryBackoff {
+			backoff = maxRecoveryBackoff
+		}
 	}
 }
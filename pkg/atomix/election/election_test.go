@@ -6,13 +6,23 @@ package election
 
 import (
 	"context"
+	"fmt"
 	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	api "github.com/atomix/atomix-api/go/atomix/primitive/election"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
 	"github.com/atomix/atomix-go-client/pkg/atomix/util/test"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestElectionOperations(t *testing.T) {
@@ -247,3 +257,1027 @@ func TestElectionOperations(t *testing.T) {
 
 	assert.NoError(t, test.Stop())
 }
+
+func TestElectionGetPriorities(t *testing.T) {
+	election := &election{
+		Client: primitive.NewClient(Type, "TestElectionGetPriorities", nil),
+		client: &testTermClient{
+			client: &testGroupClient{},
+			term: api.Term{
+				Leader:     "bar",
+				Candidates: []string{"foo", "bar", "baz", "qux"},
+			},
+		},
+	}
+
+	// Priorities are ranks in the term's candidate order with the leader first
+	priorities, err := election.GetPriorities(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"bar": 0, "foo": 1, "baz": 2, "qux": 3}, priorities)
+
+	// An election without candidates has no priorities
+	election.client = &testTermClient{client: &testGroupClient{}}
+	priorities, err = election.GetPriorities(context.TODO())
+	assert.NoError(t, err)
+	assert.NotNil(t, priorities)
+	assert.Len(t, priorities, 0)
+
+	election.client = &testTermClient{
+		client: &testGroupClient{},
+		err:    status.Error(codes.Unavailable, "unavailable"),
+	}
+	_, err = election.GetPriorities(context.TODO())
+	assert.True(t, errors.IsUnavailable(err))
+}
+
+func TestElectionEnterIdempotent(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestElectionEnterIdempotent",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	var elections []Election
+	for i := 1; i <= 2; i++ {
+		conn, err := test.CreateProxy(primitiveID)
+		assert.NoError(t, err)
+		election, err := New(context.TODO(), "TestElectionEnterIdempotent", conn, primitive.WithSessionID(fmt.Sprintf("client-%d", i)))
+		assert.NoError(t, err)
+		elections = append(elections, election)
+	}
+
+	_, err := elections[0].Enter(context.TODO())
+	assert.NoError(t, err)
+	_, err = elections[1].Enter(context.TODO())
+	assert.NoError(t, err)
+
+	// A retried Enter results in a single candidacy and does not change the instance's priority
+	for i := 0; i < 3; i++ {
+		term, err := elections[0].Enter(context.TODO())
+		assert.NoError(t, err)
+		assert.Equal(t, "client-1", term.Leader)
+		assert.Equal(t, []string{"client-1", "client-2"}, term.Candidates)
+		term, err = elections[1].Enter(context.TODO())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"client-1", "client-2"}, term.Candidates)
+	}
+
+	// A single Leave withdraws the retried candidacy
+	term, err := elections[0].Leave(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, "client-2", term.Leader)
+	assert.Equal(t, []string{"client-2"}, term.Candidates)
+
+	for _, election := range elections {
+		assert.NoError(t, election.Close(context.Background()))
+	}
+	assert.NoError(t, test.Stop())
+}
+
+func TestElectionCandidates(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestElectionCandidates",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	var elections []Election
+	for i := 1; i <= 3; i++ {
+		conn, err := test.CreateProxy(primitiveID)
+		assert.NoError(t, err)
+		election, err := New(context.TODO(), "TestElectionCandidates", conn, primitive.WithSessionID(fmt.Sprintf("client-%d", i)))
+		assert.NoError(t, err)
+		elections = append(elections, election)
+	}
+
+	// An empty election has no candidates
+	candidates, err := elections[0].Candidates(context.TODO())
+	assert.NoError(t, err)
+	assert.NotNil(t, candidates)
+	assert.Len(t, candidates, 0)
+
+	for _, election := range elections {
+		_, err := election.Enter(context.TODO())
+		assert.NoError(t, err)
+	}
+
+	// Candidates are listed in the order in which they entered with the leader first
+	candidates, err = elections[0].Candidates(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"client-1", "client-2", "client-3"}, candidates)
+
+	// The leader is listed first once leadership changes
+	_, err = elections[0].Anoint(context.TODO(), "client-3")
+	assert.NoError(t, err)
+	candidates, err = elections[1].Candidates(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, "client-3", candidates[0])
+	assert.ElementsMatch(t, []string{"client-1", "client-2", "client-3"}, candidates)
+
+	_, err = elections[2].Leave(context.TODO())
+	assert.NoError(t, err)
+	candidates, err = elections[1].Candidates(context.TODO())
+	assert.NoError(t, err)
+	assert.Len(t, candidates, 2)
+	assert.NotContains(t, candidates, "client-3")
+
+	for _, election := range elections {
+		assert.NoError(t, election.Close(context.Background()))
+	}
+	assert.NoError(t, test.Stop())
+}
+
+func TestElectionLeaveRequireSuccessor(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestElectionLeaveRequireSuccessor",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	var elections []Election
+	for i := 1; i <= 2; i++ {
+		conn, err := test.CreateProxy(primitiveID)
+		assert.NoError(t, err)
+		election, err := New(context.TODO(), "TestElectionLeaveRequireSuccessor", conn, primitive.WithSessionID(fmt.Sprintf("client-%d", i)))
+		assert.NoError(t, err)
+		_, err = election.Enter(context.TODO())
+		assert.NoError(t, err)
+		elections = append(elections, election)
+	}
+
+	// The leader leaves when there is a successor
+	term, err := elections[0].Leave(context.TODO(), WithRequireSuccessor())
+	assert.NoError(t, err)
+	assert.Equal(t, "client-2", term.Leader)
+	assert.Equal(t, []string{"client-2"}, term.Candidates)
+
+	// The sole candidate refuses to leave and remains the leader
+	_, err = elections[1].Leave(context.TODO(), WithRequireSuccessor())
+	assert.True(t, errors.IsConflict(err))
+	term, err = elections[1].GetTerm(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, "client-2", term.Leader)
+
+	// A candidate that is not the leader can always leave
+	_, err = elections[0].Enter(context.TODO())
+	assert.NoError(t, err)
+	term, err = elections[0].Leave(context.TODO(), WithRequireSuccessor())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"client-2"}, term.Candidates)
+
+	// The sole candidate leaves without the option
+	term, err = elections[1].Leave(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, "", term.Leader)
+
+	for _, election := range elections {
+		assert.NoError(t, election.Close(context.Background()))
+	}
+	assert.NoError(t, test.Stop())
+}
+
+func TestElectionLeaveGracePeriod(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestElectionLeaveGracePeriod",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	var elections []Election
+	for i := 1; i <= 2; i++ {
+		conn, err := test.CreateProxy(primitiveID)
+		assert.NoError(t, err)
+		election, err := New(context.TODO(), "TestElectionLeaveGracePeriod", conn, primitive.WithSessionID(fmt.Sprintf("client-%d", i)))
+		assert.NoError(t, err)
+		_, err = election.Enter(context.TODO())
+		assert.NoError(t, err)
+		elections = append(elections, election)
+	}
+
+	// The leave is abandoned if the context is done during the grace period
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := elections[0].Leave(ctx, WithGracePeriod(time.Minute))
+	assert.True(t, errors.IsTimeout(err))
+
+	// Leadership is handed off to the successor before the grace period
+	term, err := elections[1].GetTerm(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, "client-2", term.Leader)
+	assert.ElementsMatch(t, []string{"client-1", "client-2"}, term.Candidates)
+
+	// Withdrawal is delayed by the grace period
+	start := time.Now()
+	term, err = elections[0].Leave(context.TODO(), WithGracePeriod(100*time.Millisecond))
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(100*time.Millisecond))
+	assert.Equal(t, "client-2", term.Leader)
+	assert.Equal(t, []string{"client-2"}, term.Candidates)
+
+	// The leader leaves after the grace period if there is no successor
+	term, err = elections[1].Leave(context.TODO(), WithGracePeriod(10*time.Millisecond))
+	assert.NoError(t, err)
+	assert.Equal(t, "", term.Leader)
+	assert.Len(t, term.Candidates, 0)
+
+	for _, election := range elections {
+		assert.NoError(t, election.Close(context.Background()))
+	}
+	assert.NoError(t, test.Stop())
+}
+
+func TestElectionWatchOpen(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestElectionWatchOpen",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	election, err := New(context.TODO(), "TestElectionWatchOpen", conn, primitive.WithSessionID("client-1"))
+	assert.NoError(t, err)
+
+	ch := make(chan Event)
+	err = election.Watch(context.TODO(), ch)
+	assert.NoError(t, err)
+
+	_, err = election.Enter(context.TODO())
+	assert.NoError(t, err)
+
+	// The open marker completes the handshake and must not be delivered to the consumer
+	event := <-ch
+	assert.Equal(t, EventChange, event.Type)
+	assert.Equal(t, election.ID(), event.Term.Leader)
+
+	assert.NoError(t, election.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+// testEventsClient is an election events stream that replays a list of responses
+// Once all responses have been replayed, a blocked stream blocks until the stream's context is done.
+type testEventsClient struct {
+	grpc.ClientStream
+	ctx       context.Context
+	blocked   bool
+	err       error
+	responses []*api.EventsResponse
+}
+
+func (c *testEventsClient) Recv() (*api.EventsResponse, error) {
+	if len(c.responses) > 0 {
+		response := c.responses[0]
+		c.responses = c.responses[1:]
+		return response, nil
+	}
+	if c.blocked {
+		<-c.ctx.Done()
+		return nil, c.ctx.Err()
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	return nil, io.EOF
+}
+
+// testElectionClient is an election client whose first failures watch streams never open
+// If hold is set, opened streams remain open until their context is done. If err is set, opened streams
+// fail with the error once all responses have been replayed.
+type testElectionClient struct {
+	api.LeaderElectionServiceClient
+	failures int32
+	attempts int32
+	hold     bool
+	err      error
+}
+
+func (c *testElectionClient) Events(ctx context.Context, request *api.EventsRequest, opts ...grpc.CallOption) (api.LeaderElectionService_EventsClient, error) {
+	attempt := atomic.AddInt32(&c.attempts, 1)
+	if attempt <= c.failures {
+		return &testEventsClient{ctx: ctx, blocked: true}, nil
+	}
+	return &testEventsClient{
+		ctx:     ctx,
+		blocked: c.hold,
+		err:     c.err,
+		responses: []*api.EventsResponse{
+			{},
+			{
+				Event: api.Event{
+					Type: api.Event_CHANGED,
+					Term: api.Term{
+						Leader: "foo",
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func newTestElection(client api.LeaderElectionServiceClient) *election {
+	return &election{
+		Client: primitive.NewClient(Type, "test", nil),
+		client: client,
+	}
+}
+
+func TestElectionWatchRetry(t *testing.T) {
+	client := &testElectionClient{failures: 2}
+	election := newTestElection(client)
+
+	ch := make(chan Event)
+	err := election.Watch(context.Background(), ch, WithHandshakeTimeout(10*time.Millisecond), WithHandshakeRetry(3, time.Millisecond))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&client.attempts))
+
+	event := <-ch
+	assert.Equal(t, EventChange, event.Type)
+	assert.Equal(t, "foo", event.Term.Leader)
+
+	_, ok := <-ch
+	assert.False(t, ok)
+}
+
+func TestElectionWatchRetryExhausted(t *testing.T) {
+	client := &testElectionClient{failures: 5}
+	election := newTestElection(client)
+
+	ch := make(chan Event)
+	err := election.Watch(context.Background(), ch, WithHandshakeTimeout(10*time.Millisecond), WithHandshakeRetry(3, time.Millisecond))
+	assert.Error(t, err)
+	assert.True(t, errors.IsTimeout(err))
+	assert.Contains(t, err.Error(), "3 attempts")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&client.attempts))
+
+	// Retries must respect the caller's context
+	client = &testElectionClient{failures: 5}
+	election = newTestElection(client)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = election.Watch(ctx, ch, WithHandshakeRetry(5, time.Millisecond))
+	assert.Error(t, err)
+	assert.True(t, errors.IsTimeout(err))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&client.attempts))
+}
+
+// testFailingElectionClient is an election client whose first watch streams fail with the given errors
+type testFailingElectionClient struct {
+	testElectionClient
+	errs []error
+}
+
+func (c *testFailingElectionClient) Events(ctx context.Context, request *api.EventsRequest, opts ...grpc.CallOption) (api.LeaderElectionService_EventsClient, error) {
+	if len(c.errs) > 0 {
+		atomic.AddInt32(&c.attempts, 1)
+		err := c.errs[0]
+		c.errs = c.errs[1:]
+		return &testEventsClient{ctx: ctx, err: err}, nil
+	}
+	return c.testElectionClient.Events(ctx, request, opts...)
+}
+
+func TestElectionWatchReconnectableCodes(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	invalid := status.Error(codes.InvalidArgument, "invalid")
+	denied := status.Error(codes.PermissionDenied, "denied")
+	deadline := status.Error(codes.DeadlineExceeded, "deadline exceeded")
+
+	// Retryable codes are retried by default
+	client := &testFailingElectionClient{errs: []error{unavailable, status.Error(codes.Internal, "internal")}}
+	ch := make(chan Event)
+	err := newTestElection(client).Watch(context.Background(), ch, WithHandshakeRetry(3, time.Millisecond))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&client.attempts))
+	event := <-ch
+	assert.Equal(t, "foo", event.Term.Leader)
+	for range ch {
+	}
+
+	// Client errors terminate the watch by default
+	client = &testFailingElectionClient{errs: []error{invalid}}
+	err = newTestElection(client).Watch(context.Background(), make(chan Event), WithHandshakeRetry(3, time.Millisecond))
+	assert.True(t, errors.IsInvalid(err))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&client.attempts))
+
+	// Deadlines exceeded on the server terminate the watch by default
+	client = &testFailingElectionClient{errs: []error{deadline}}
+	err = newTestElection(client).Watch(context.Background(), make(chan Event), WithHandshakeRetry(3, time.Millisecond))
+	assert.True(t, errors.IsTimeout(err))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&client.attempts))
+
+	client = &testFailingElectionClient{errs: []error{deadline}}
+	ch = make(chan Event)
+	err = newTestElection(client).Watch(context.Background(), ch,
+		WithHandshakeRetry(3, time.Millisecond),
+		WithReconnectableCodes(codes.DeadlineExceeded))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&client.attempts))
+	for range ch {
+	}
+
+	// The reconnectable codes can be overridden
+	client = &testFailingElectionClient{errs: []error{denied}}
+	ch = make(chan Event)
+	err = newTestElection(client).Watch(context.Background(), ch,
+		WithHandshakeRetry(3, time.Millisecond),
+		WithReconnectableCodes(codes.PermissionDenied))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&client.attempts))
+	for range ch {
+	}
+
+	client = &testFailingElectionClient{errs: []error{unavailable}}
+	err = newTestElection(client).Watch(context.Background(), make(chan Event),
+		WithHandshakeRetry(3, time.Millisecond),
+		WithReconnectableCodes(codes.PermissionDenied))
+	assert.True(t, errors.IsUnavailable(err))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&client.attempts))
+}
+
+func TestElectionWatchState(t *testing.T) {
+	var states []WatchState
+	listener := func(state WatchState) {
+		states = append(states, state)
+	}
+
+	election := newTestElection(&testElectionClient{})
+	ch := make(chan Event)
+	err := election.Watch(context.Background(), ch, WithStateListener(listener))
+	assert.NoError(t, err)
+	for range ch {
+	}
+	assert.Equal(t, []WatchState{WatchConnecting, WatchOpen, WatchClosed}, states)
+
+	// Failed attempts to open the stream are reported as reconnects
+	states = nil
+	election = newTestElection(&testElectionClient{failures: 2})
+	ch = make(chan Event)
+	err = election.Watch(context.Background(), ch,
+		WithStateListener(listener),
+		WithHandshakeTimeout(10*time.Millisecond),
+		WithHandshakeRetry(3, time.Millisecond))
+	assert.NoError(t, err)
+	for range ch {
+	}
+	assert.Equal(t, []WatchState{WatchConnecting, WatchReconnecting, WatchReconnecting, WatchOpen, WatchClosed}, states)
+
+	states = nil
+	election = newTestElection(&testElectionClient{failures: 2})
+	ch = make(chan Event)
+	err = election.Watch(context.Background(), ch,
+		WithStateListener(listener),
+		WithHandshakeTimeout(10*time.Millisecond),
+		WithHandshakeRetry(2, time.Millisecond))
+	assert.Error(t, err)
+	assert.Equal(t, []WatchState{WatchConnecting, WatchReconnecting, WatchClosed}, states)
+}
+
+func TestElectionWatchReconnectDebounce(t *testing.T) {
+	var states []WatchState
+	listener := func(state WatchState) {
+		states = append(states, state)
+	}
+
+	// Rapid reconnects within the debounce window are reported once
+	election := newTestElection(&testElectionClient{failures: 4})
+	ch := make(chan Event)
+	err := election.Watch(context.Background(), ch,
+		WithStateListener(listener),
+		WithHandshakeTimeout(10*time.Millisecond),
+		WithHandshakeRetry(5, time.Millisecond),
+		WithReconnectDebounce(time.Minute))
+	assert.NoError(t, err)
+	for range ch {
+	}
+	assert.Equal(t, []WatchState{WatchConnecting, WatchReconnecting, WatchOpen, WatchClosed}, states)
+
+	// Reconnects separated by a quiet period are each reported
+	states = nil
+	election = newTestElection(&testElectionClient{failures: 2})
+	ch = make(chan Event)
+	err = election.Watch(context.Background(), ch,
+		WithStateListener(listener),
+		WithHandshakeTimeout(10*time.Millisecond),
+		WithHandshakeRetry(3, 50*time.Millisecond),
+		WithReconnectDebounce(20*time.Millisecond))
+	assert.NoError(t, err)
+	for range ch {
+	}
+	assert.Equal(t, []WatchState{WatchConnecting, WatchReconnecting, WatchReconnecting, WatchOpen, WatchClosed}, states)
+}
+
+func TestElectionWatchCloseReason(t *testing.T) {
+	var reason CloseReason
+	var closeErr error
+	listener := func(r CloseReason, err error) {
+		reason, closeErr = r, err
+	}
+
+	// The server ending the stream is reported as EOF
+	election := newTestElection(&testElectionClient{})
+	ch := make(chan Event)
+	err := election.Watch(context.Background(), ch, WithCloseListener(listener))
+	assert.NoError(t, err)
+	for range ch {
+	}
+	assert.Equal(t, CloseEOF, reason)
+	assert.NoError(t, closeErr)
+
+	// Cancelling the watch context is reported as canceled
+	election = newTestElection(&testElectionClient{hold: true})
+	ctx, cancel := context.WithCancel(context.Background())
+	ch = make(chan Event)
+	err = election.Watch(ctx, ch, WithCloseListener(listener))
+	assert.NoError(t, err)
+	<-ch
+	cancel()
+	for range ch {
+	}
+	assert.Equal(t, CloseCanceled, reason)
+	assert.NoError(t, closeErr)
+
+	// Stream failures are reported as errors
+	election = newTestElection(&testElectionClient{err: status.Error(codes.Unavailable, "unavailable")})
+	ch = make(chan Event)
+	err = election.Watch(context.Background(), ch, WithCloseListener(listener))
+	assert.NoError(t, err)
+	for range ch {
+	}
+	assert.Equal(t, CloseError, reason)
+	assert.True(t, errors.IsUnavailable(closeErr))
+
+	// Panicking listeners do not take down the watch
+	election = newTestElection(&testElectionClient{})
+	ch = make(chan Event)
+	stateListener := func(state WatchState) {
+		panic("state listener failed")
+	}
+	closeListener := func(r CloseReason, err error) {
+		reason, closeErr = r, err
+		panic("close listener failed")
+	}
+	err = election.Watch(context.Background(), ch, WithStateListener(stateListener), WithCloseListener(closeListener))
+	assert.NoError(t, err)
+	for range ch {
+	}
+	assert.Equal(t, CloseEOF, reason)
+	assert.NoError(t, closeErr)
+}
+
+func TestElectionRankChanges(t *testing.T) {
+	// Candidates that joined the election gained rank
+	changes := RankChanges(Term{}, Term{Leader: "a", Candidates: []string{"a", "b"}})
+	assert.Equal(t, []RankChange{
+		{ID: "a", PreviousRank: -1, Rank: 0},
+		{ID: "b", PreviousRank: -1, Rank: 1},
+	}, changes)
+	assert.True(t, changes[0].Gained())
+	assert.False(t, changes[0].Lost())
+
+	// Only candidates whose rank changed are reported
+	changes = RankChanges(
+		Term{Leader: "a", Candidates: []string{"a", "b", "c", "d"}},
+		Term{Leader: "a", Candidates: []string{"a", "d", "c", "b"}})
+	assert.Equal(t, []RankChange{
+		{ID: "d", PreviousRank: 3, Rank: 1},
+		{ID: "b", PreviousRank: 1, Rank: 3},
+	}, changes)
+	assert.True(t, changes[0].Gained())
+	assert.True(t, changes[1].Lost())
+
+	// A new leader is ranked first, and candidates that left the election lost rank
+	changes = RankChanges(
+		Term{Leader: "a", Candidates: []string{"a", "b", "c"}},
+		Term{Leader: "c", Candidates: []string{"b", "c"}})
+	assert.Equal(t, []RankChange{
+		{ID: "c", PreviousRank: 2, Rank: 0},
+		{ID: "a", PreviousRank: 0, Rank: -1},
+	}, changes)
+	assert.True(t, changes[1].Lost())
+
+	assert.Len(t, RankChanges(Term{Leader: "a", Candidates: []string{"a"}}, Term{Leader: "a", Candidates: []string{"a"}}), 0)
+}
+
+// testTermsElectionClient is an election client whose watch streams replay changes to the given terms
+type testTermsElectionClient struct {
+	api.LeaderElectionServiceClient
+	terms []api.Term
+}
+
+func (c *testTermsElectionClient) Events(ctx context.Context, request *api.EventsRequest, opts ...grpc.CallOption) (api.LeaderElectionService_EventsClient, error) {
+	responses := []*api.EventsResponse{{}}
+	for _, term := range c.terms {
+		responses = append(responses, &api.EventsResponse{
+			Event: api.Event{
+				Type: api.Event_CHANGED,
+				Term: term,
+			},
+		})
+	}
+	return &testEventsClient{ctx: ctx, responses: responses}, nil
+}
+
+func TestElectionWatchRankChanges(t *testing.T) {
+	election := newTestElection(&testTermsElectionClient{
+		terms: []api.Term{
+			{Leader: "a", Candidates: []string{"a", "b"}},
+			{Leader: "b", Candidates: []string{"a", "b"}},
+		},
+	})
+	ch := make(chan Event)
+	assert.NoError(t, election.Watch(context.Background(), ch))
+
+	// The first event has no previous term to compare against
+	event := <-ch
+	assert.Nil(t, event.RankChanges)
+
+	event = <-ch
+	assert.Equal(t, []RankChange{
+		{ID: "b", PreviousRank: 1, Rank: 0},
+		{ID: "a", PreviousRank: 0, Rank: 1},
+	}, event.RankChanges)
+
+	_, ok := <-ch
+	assert.False(t, ok)
+}
+
+func TestElectionWatchPreviousLeader(t *testing.T) {
+	election := newTestElection(&testTermsElectionClient{
+		terms: []api.Term{
+			{Leader: "a", Candidates: []string{"a", "b", "c"}},
+			{Leader: "b", Candidates: []string{"b", "c"}},
+			{Leader: "b", Candidates: []string{"b", "c", "a"}},
+			{Candidates: []string{}},
+			{Leader: "c", Candidates: []string{"c"}},
+		},
+	})
+	ch := make(chan Event)
+	assert.NoError(t, election.Watch(context.Background(), ch))
+
+	// The first event has no previous term
+	event := <-ch
+	assert.Equal(t, "a", event.Term.Leader)
+	assert.Equal(t, "", event.PreviousLeader)
+
+	event = <-ch
+	assert.Equal(t, "b", event.Term.Leader)
+	assert.Equal(t, "a", event.PreviousLeader)
+
+	event = <-ch
+	assert.Equal(t, "b", event.Term.Leader)
+	assert.Equal(t, "b", event.PreviousLeader)
+
+	event = <-ch
+	assert.Equal(t, "", event.Term.Leader)
+	assert.Equal(t, "b", event.PreviousLeader)
+
+	event = <-ch
+	assert.Equal(t, "c", event.Term.Leader)
+	assert.Equal(t, "", event.PreviousLeader)
+
+	_, ok := <-ch
+	assert.False(t, ok)
+}
+
+func TestElectionWatchShutdown(t *testing.T) {
+	leaks := test.NewLeakCheck()
+
+	election := newTestElection(&testElectionClient{failures: 1, hold: true})
+
+	wg := &sync.WaitGroup{}
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan Event)
+	err := election.Watch(ctx, ch,
+		WithWaitGroup(wg),
+		WithHandshakeTimeout(10*time.Millisecond),
+		WithHandshakeRetry(2, time.Millisecond))
+	assert.NoError(t, err)
+
+	event := <-ch
+	assert.Equal(t, EventChange, event.Type)
+
+	cancel()
+	wg.Wait()
+
+	_, ok := <-ch
+	assert.False(t, ok)
+	assert.NoError(t, leaks.Wait(time.Second))
+}
+
+func TestElectionTransferLeadership(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestElectionTransferLeadership",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	var elections []Election
+	for i := 1; i <= 3; i++ {
+		conn, err := test.CreateProxy(primitiveID)
+		assert.NoError(t, err)
+		election, err := New(context.TODO(), "TestElectionTransferLeadership", conn, primitive.WithSessionID(fmt.Sprintf("client-%d", i)))
+		assert.NoError(t, err)
+		elections = append(elections, election)
+	}
+	election1, election2, election3 := elections[0], elections[1], elections[2]
+
+	_, err := election1.Enter(context.TODO())
+	assert.NoError(t, err)
+	_, err = election2.Enter(context.TODO())
+	assert.NoError(t, err)
+
+	// Leadership cannot be transferred to an instance that is not a candidate
+	_, err = election1.TransferLeadership(context.TODO(), election3.ID())
+	assert.True(t, errors.IsNotFound(err))
+
+	term, err := election1.GetTerm(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, election1.ID(), term.Leader)
+
+	_, err = election3.Enter(context.TODO())
+	assert.NoError(t, err)
+
+	// Transferring leadership retains the previous leader as a candidate
+	term, err = election1.TransferLeadership(context.TODO(), election2.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, election2.ID(), term.Leader)
+	assert.Len(t, term.Candidates, 3)
+
+	// Transferring leadership to the current leader is a no-op
+	revision := term.Revision
+	term, err = election1.TransferLeadership(context.TODO(), election2.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, election2.ID(), term.Leader)
+	assert.Equal(t, revision, term.Revision)
+
+	// The previous leader is evicted with WithEvictLeader
+	term, err = election2.TransferLeadership(context.TODO(), election3.ID(), WithEvictLeader())
+	assert.NoError(t, err)
+	assert.Equal(t, election3.ID(), term.Leader)
+	assert.ElementsMatch(t, []string{election3.ID(), election1.ID()}, term.Candidates)
+
+	term, err = election1.GetTerm(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, election3.ID(), term.Leader)
+	assert.ElementsMatch(t, []string{election3.ID(), election1.ID()}, term.Candidates)
+
+	for _, election := range elections {
+		assert.NoError(t, election.Close(context.Background()))
+	}
+	assert.NoError(t, test.Stop())
+}
+
+// testRecoveringElectionClient is an election client whose first watch stream fails with an error
+// Subsequent streams remain open until their context is done.
+type testRecoveringElectionClient struct {
+	api.LeaderElectionServiceClient
+	attempts int32
+	failures int32
+	err      error
+}
+
+func (c *testRecoveringElectionClient) Events(ctx context.Context, request *api.EventsRequest, opts ...grpc.CallOption) (api.LeaderElectionService_EventsClient, error) {
+	attempt := atomic.AddInt32(&c.attempts, 1)
+	if attempt > 1 && attempt <= c.failures+1 {
+		return &testEventsClient{ctx: ctx, err: c.err}, nil
+	}
+	leader := "foo"
+	if attempt > 1 {
+		leader = "bar"
+	}
+	return &testEventsClient{
+		ctx:     ctx,
+		blocked: attempt > 1,
+		err:     c.err,
+		responses: []*api.EventsResponse{
+			{},
+			{
+				Event: api.Event{
+					Type: api.Event_CHANGED,
+					Term: api.Term{
+						Leader:     leader,
+						Candidates: []string{"foo", "bar"},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func TestElectionWatchKeepOpenOnError(t *testing.T) {
+	client := &testRecoveringElectionClient{
+		err: status.Error(codes.Unavailable, "unavailable"),
+	}
+	election := newTestElection(client)
+
+	var states []WatchState
+	mu := &sync.Mutex{}
+	listener := func(state WatchState) {
+		mu.Lock()
+		defer mu.Unlock()
+		states = append(states, state)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan Event)
+	err := election.Watch(ctx, ch, WithKeepOpenOnError(), WithHandshakeRetry(1, 10*time.Millisecond), WithStateListener(listener))
+	assert.NoError(t, err)
+
+	event := <-ch
+	assert.Equal(t, EventChange, event.Type)
+	assert.Equal(t, "foo", event.Term.Leader)
+
+	// A recoverable failure delivers an error event and the channel remains open
+	event = <-ch
+	assert.Equal(t, EventError, event.Type)
+	assert.True(t, errors.IsUnavailable(event.Err))
+
+	// Events from the recovered stream are delivered on the same channel, continuing from the previous term
+	event = <-ch
+	assert.Equal(t, EventChange, event.Type)
+	assert.Equal(t, "bar", event.Term.Leader)
+	assert.Equal(t, "foo", event.PreviousLeader)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&client.attempts))
+
+	cancel()
+	_, ok := <-ch
+	assert.False(t, ok)
+	mu.Lock()
+	assert.Equal(t, []WatchState{WatchConnecting, WatchOpen, WatchReconnecting, WatchOpen, WatchClosed}, states)
+	mu.Unlock()
+}
+
+func TestElectionWatchKeepOpenOnErrorDebounce(t *testing.T) {
+	var states []WatchState
+	mu := &sync.Mutex{}
+	listener := func(state WatchState) {
+		mu.Lock()
+		defer mu.Unlock()
+		states = append(states, state)
+	}
+
+	// Failed attempts to recover the stream are reported as reconnects
+	election := newTestElection(&testRecoveringElectionClient{
+		failures: 2,
+		err:      status.Error(codes.Unavailable, "unavailable"),
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan Event)
+	err := election.Watch(ctx, ch, WithKeepOpenOnError(), WithHandshakeRetry(1, time.Millisecond), WithStateListener(listener))
+	assert.NoError(t, err)
+	assert.Equal(t, EventChange, (<-ch).Type)
+	assert.Equal(t, EventError, (<-ch).Type)
+	assert.Equal(t, "bar", (<-ch).Term.Leader)
+	cancel()
+	for range ch {
+	}
+	mu.Lock()
+	assert.Equal(t, []WatchState{WatchConnecting, WatchOpen, WatchReconnecting, WatchReconnecting, WatchReconnecting, WatchOpen, WatchClosed}, states)
+	mu.Unlock()
+
+	// Reconnects made while recovering the stream are debounced
+	states = nil
+	election = newTestElection(&testRecoveringElectionClient{
+		failures: 2,
+		err:      status.Error(codes.Unavailable, "unavailable"),
+	})
+	ctx, cancel = context.WithCancel(context.Background())
+	ch = make(chan Event)
+	err = election.Watch(ctx, ch, WithKeepOpenOnError(), WithHandshakeRetry(1, time.Millisecond),
+		WithStateListener(listener), WithReconnectDebounce(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, EventChange, (<-ch).Type)
+	assert.Equal(t, EventError, (<-ch).Type)
+	assert.Equal(t, "bar", (<-ch).Term.Leader)
+	cancel()
+	for range ch {
+	}
+	mu.Lock()
+	assert.Equal(t, []WatchState{WatchConnecting, WatchOpen, WatchReconnecting, WatchOpen, WatchClosed}, states)
+	mu.Unlock()
+}
+
+func TestElectionWatchCloseOnError(t *testing.T) {
+	// Without the option, the channel is closed when the stream fails
+	election := newTestElection(&testRecoveringElectionClient{
+		err: status.Error(codes.Unavailable, "unavailable"),
+	})
+	ch := make(chan Event)
+	assert.NoError(t, election.Watch(context.Background(), ch))
+	event := <-ch
+	assert.Equal(t, EventChange, event.Type)
+	_, ok := <-ch
+	assert.False(t, ok)
+
+	// Failures that are not reconnectable close the channel without an error event
+	election = newTestElection(&testRecoveringElectionClient{
+		err: status.Error(codes.PermissionDenied, "denied"),
+	})
+	ch = make(chan Event)
+	assert.NoError(t, election.Watch(context.Background(), ch, WithKeepOpenOnError()))
+	event = <-ch
+	assert.Equal(t, EventChange, event.Type)
+	_, ok = <-ch
+	assert.False(t, ok)
+}
+
+// testFakeEventsClient is an election events stream backed by a FakeEventStream
+type testFakeEventsClient struct {
+	*test.FakeEventStream
+}
+
+func (c testFakeEventsClient) Recv() (*api.EventsResponse, error) {
+	response := &api.EventsResponse{}
+	if err := c.RecvMsg(response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// testFakeStreamElectionClient is an election client whose watch streams are driven by the test
+// Each stream opened by the election is passed to the test on the streams channel.
+type testFakeStreamElectionClient struct {
+	api.LeaderElectionServiceClient
+	streams chan *test.FakeEventStream
+}
+
+func (c *testFakeStreamElectionClient) Events(ctx context.Context, request *api.EventsRequest, opts ...grpc.CallOption) (api.LeaderElectionService_EventsClient, error) {
+	stream := test.NewFakeEventStream(ctx)
+	c.streams <- stream
+	return testFakeEventsClient{stream}, nil
+}
+
+func newTestChangeResponse(leader string) *api.EventsResponse {
+	return &api.EventsResponse{
+		Event: api.Event{
+			Type: api.Event_CHANGED,
+			Term: api.Term{
+				Leader: leader,
+			},
+		},
+	}
+}
+
+func TestElectionWatchFakeStream(t *testing.T) {
+	client := &testFakeStreamElectionClient{streams: make(chan *test.FakeEventStream, 1)}
+	election := newTestElection(client)
+
+	var reason CloseReason
+	listener := func(r CloseReason, err error) {
+		reason = r
+	}
+
+	// The watch is open once the stream's open marker has been received
+	ch := make(chan Event)
+	errCh := make(chan error)
+	go func() {
+		errCh <- election.Watch(context.Background(), ch, WithKeepOpenOnError(), WithHandshakeRetry(1, time.Millisecond), WithCloseListener(listener))
+	}()
+	stream := <-client.streams
+	stream.Send(&api.EventsResponse{})
+	assert.NoError(t, <-errCh)
+
+	stream.Send(newTestChangeResponse("foo"))
+	event := <-ch
+	assert.Equal(t, EventChange, event.Type)
+	assert.Equal(t, "foo", event.Term.Leader)
+
+	// A stream error is delivered as an error event and the watch is reopened on a new stream
+	stream.Fail(status.Error(codes.Unavailable, "unavailable"))
+	event = <-ch
+	assert.Equal(t, EventError, event.Type)
+	assert.True(t, errors.IsUnavailable(event.Err))
+
+	stream = <-client.streams
+	stream.Send(&api.EventsResponse{})
+	stream.Send(newTestChangeResponse("bar"))
+	event = <-ch
+	assert.Equal(t, EventChange, event.Type)
+	assert.Equal(t, "bar", event.Term.Leader)
+	assert.Equal(t, "foo", event.PreviousLeader)
+
+	// The server ending the stream closes the channel
+	stream.EOF()
+	_, ok := <-ch
+	assert.False(t, ok)
+	assert.Equal(t, CloseEOF, reason)
+}
@@ -5,7 +5,11 @@
 package election
 
 import (
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"google.golang.org/grpc/codes"
+	"sync"
+	"time"
 )
 
 // Option is a election option
@@ -16,3 +20,291 @@ type Option interface {
 
 // newElectionOptions is election options
 type newElectionOptions struct{}
+
+// WatchOption is an option for the Watch method
+type WatchOption interface {
+	applyWatch(options *watchOptions)
+}
+
+// watchOptions is election watch options
+type watchOptions struct {
+	handshakeTimeout  time.Duration
+	attempts          int
+	backoff           time.Duration
+	stateListener     func(WatchState)
+	closeListener     func(CloseReason, error)
+	reconnectDebounce time.Duration
+	reconnects        *reconnectDebouncer
+	waitGroup         *sync.WaitGroup
+	invokeCallback    func(func() error) error
+	reconnectable     map[codes.Code]bool
+	keepOpen          bool
+}
+
+// defaultRecoveryBackoff is the default initial delay between attempts to recover a failed watch stream
+const defaultRecoveryBackoff = 100 * time.Millisecond
+
+// maxRecoveryBackoff is the maximum delay between attempts to recover a failed watch stream
+const maxRecoveryBackoff = 5 * time.Second
+
+// defaultReconnectableCodes is the set of codes for which failed attempts to open a watch are retried by default
+var defaultReconnectableCodes = []codes.Code{
+	codes.Unavailable,
+	codes.Internal,
+}
+
+// newCodeSet returns a set of the given codes
+func newCodeSet(list []codes.Code) map[codes.Code]bool {
+	set := make(map[codes.Code]bool)
+	for _, code := range list {
+		set[code] = true
+	}
+	return set
+}
+
+// isReconnectable returns whether a failed attempt to open the watch stream may be retried
+func (o watchOptions) isReconnectable(err error) bool {
+	return o.reconnectable[errors.Code(err)]
+}
+
+// notify notifies the state listener of a watch state change
+func (o watchOptions) notify(state WatchState) {
+	if o.stateListener != nil {
+		o.invoke(func() {
+			o.stateListener(state)
+		})
+	}
+}
+
+// notifyReconnect notifies the state listener of a reconnect unless it is within the debounce window
+func (o watchOptions) notifyReconnect() {
+	if o.reconnects == nil || o.reconnects.report() {
+		o.notify(WatchReconnecting)
+	}
+}
+
+// reconnectDebouncer tracks the reconnects of a watch, including reconnects made while recovering the
+// stream, to coalesce reconnect notifications
+type reconnectDebouncer struct {
+	window time.Duration
+	last   time.Time
+	mu     sync.Mutex
+}
+
+// report records a reconnect and returns whether it should be reported
+// Reconnects within the debounce window of the previous reconnect are not reported.
+func (d *reconnectDebouncer) report() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	report := d.last.IsZero() || now.Sub(d.last) >= d.window
+	d.last = now
+	return report
+}
+
+// notifyClose notifies the close listener of the reason the watch was closed
+func (o watchOptions) notifyClose(reason CloseReason, err error) {
+	if o.closeListener != nil {
+		o.invoke(func() {
+			o.closeListener(reason, err)
+		})
+	}
+}
+
+// invoke invokes a listener, logging the error if the listener panics
+func (o watchOptions) invoke(listener func()) {
+	if err := o.invokeCallback(func() error {
+		listener()
+		return nil
+	}); err != nil {
+		log.Errorf("Watch listener failed: %v", err)
+	}
+}
+
+// WithHandshakeTimeout returns a Watch option that fails the watch if the stream is not opened within
+// the given timeout
+func WithHandshakeTimeout(timeout time.Duration) WatchOption {
+	return handshakeTimeoutOption{timeout: timeout}
+}
+
+type handshakeTimeoutOption struct {
+	timeout time.Duration
+}
+
+func (o handshakeTimeoutOption) applyWatch(options *watchOptions) {
+	options.handshakeTimeout = o.timeout
+}
+
+// WithHandshakeRetry returns a Watch option that retries opening the watch stream up to the given
+// number of attempts
+// The delay between attempts starts at the given backoff and doubles after each failed attempt.
+func WithHandshakeRetry(attempts int, backoff time.Duration) WatchOption {
+	return handshakeRetryOption{attempts: attempts, backoff: backoff}
+}
+
+type handshakeRetryOption struct {
+	attempts int
+	backoff  time.Duration
+}
+
+func (o handshakeRetryOption) applyWatch(options *watchOptions) {
+	options.attempts = o.attempts
+	options.backoff = o.backoff
+}
+
+// WithReconnectableCodes returns a Watch option that sets the gRPC status codes for which failed attempts to
+// open the watch stream are retried
+// Attempts that fail with any other code terminate the watch, and the error is returned by Watch. By default,
+// attempts are retried on Unavailable and Internal. Attempts whose handshake times out (see
+// WithHandshakeTimeout) are always retried, but a DeadlineExceeded status returned by the server is retried
+// only if it is included in the codes. Retries are made only if the WithHandshakeRetry option is provided.
+func WithReconnectableCodes(reconnectable ...codes.Code) WatchOption {
+	return reconnectableCodesOption{codes: reconnectable}
+}
+
+type reconnectableCodesOption struct {
+	codes []codes.Code
+}
+
+func (o reconnectableCodesOption) applyWatch(options *watchOptions) {
+	options.reconnectable = newCodeSet(o.codes)
+}
+
+// WithKeepOpenOnError returns a Watch option that keeps the watch channel open when the watch stream fails
+// By default, the channel is closed when an open watch stream fails, and the consumer must watch the election
+// again. With this option, a stream that fails with a reconnectable error (see WithReconnectableCodes) delivers
+// an EventError event carrying the error, and the watch is reopened on the same channel. Attempts to reopen the
+// stream are retried until the context is done, starting at the backoff set by WithHandshakeRetry and doubling
+// after each failed attempt. The channel is closed if the stream fails with any other error or cannot be
+// reopened.
+func WithKeepOpenOnError() WatchOption {
+	return keepOpenOnErrorOption{}
+}
+
+type keepOpenOnErrorOption struct{}
+
+func (o keepOpenOnErrorOption) applyWatch(options *watchOptions) {
+	options.keepOpen = true
+}
+
+// WithStateListener returns a Watch option that notifies the given listener of changes to the state of the watch
+// The listener is called synchronously and must not block. A panic raised by the listener is recovered and
+// logged unless panic recovery has been disabled for the election.
+func WithStateListener(listener func(WatchState)) WatchOption {
+	return stateListenerOption{listener: listener}
+}
+
+// WithCloseListener returns a Watch option that notifies the given listener of the reason the watch was closed
+// The listener is called once, before the watch channel is closed, with the CloseReason and, if the stream
+// failed, the error that caused it to fail. The listener is not called if the watch stream is never opened.
+func WithCloseListener(listener func(reason CloseReason, err error)) WatchOption {
+	return closeListenerOption{listener: listener}
+}
+
+type closeListenerOption struct {
+	listener func(CloseReason, error)
+}
+
+func (o closeListenerOption) applyWatch(options *watchOptions) {
+	options.closeListener = o.listener
+}
+
+// WithReconnectDebounce returns a Watch option that coalesces reconnect notifications
+// While the watch stream is flapping, the state listener is notified of the first reconnect and further
+// reconnects are reported only once no reconnect has occurred for the given window. This applies both to
+// handshake retries and to the recovery of streams opened with WithKeepOpenOnError. Reconnection attempts
+// are not delayed by the debounce window.
+func WithReconnectDebounce(window time.Duration) WatchOption {
+	return reconnectDebounceOption{window: window}
+}
+
+type reconnectDebounceOption struct {
+	window time.Duration
+}
+
+func (o reconnectDebounceOption) applyWatch(options *watchOptions) {
+	options.reconnectDebounce = o.window
+}
+
+type stateListenerOption struct {
+	listener func(WatchState)
+}
+
+func (o stateListenerOption) applyWatch(options *watchOptions) {
+	options.stateListener = o.listener
+}
+
+// WithWaitGroup returns a Watch option that tracks the watch's background goroutine in the given WaitGroup
+// Once the watch's context has been cancelled, callers can wait on the WaitGroup to ensure the watch
+// has been fully shut down.
+func WithWaitGroup(wg *sync.WaitGroup) WatchOption {
+	return waitGroupOption{wg: wg}
+}
+
+type waitGroupOption struct {
+	wg *sync.WaitGroup
+}
+
+func (o waitGroupOption) applyWatch(options *watchOptions) {
+	options.waitGroup = o.wg
+}
+
+// LeaveOption is an option for Leave calls
+type LeaveOption interface {
+	applyLeave(options *leaveOptions)
+}
+
+// leaveOptions is a set of Leave options
+type leaveOptions struct {
+	grace            time.Duration
+	requireSuccessor bool
+}
+
+// WithGracePeriod delays withdrawal from the election by the given grace period
+// If the instance is the leader, leadership is handed off to the next candidate before the grace period
+// begins, allowing the successor to take over before the instance leaves, e.g. during rolling restarts.
+func WithGracePeriod(grace time.Duration) LeaveOption {
+	return gracePeriodOption{grace: grace}
+}
+
+type gracePeriodOption struct {
+	grace time.Duration
+}
+
+func (o gracePeriodOption) applyLeave(options *leaveOptions) {
+	options.grace = o.grace
+}
+
+// WithRequireSuccessor prevents the leader from leaving the election when there is no other candidate
+// If the instance is the leader and the only candidate, Leave fails with an error matching
+// errors.ErrConflict and the instance remains the leader, so the election is not left without a leader.
+func WithRequireSuccessor() LeaveOption {
+	return requireSuccessorOption{}
+}
+
+type requireSuccessorOption struct{}
+
+func (o requireSuccessorOption) applyLeave(options *leaveOptions) {
+	options.requireSuccessor = true
+}
+
+// TransferOption is an option for TransferLeadership calls
+type TransferOption interface {
+	applyTransfer(options *transferOptions)
+}
+
+// transferOptions is a set of TransferLeadership options
+type transferOptions struct {
+	evict bool
+}
+
+// WithEvictLeader evicts the previous leader from the election once leadership has been transferred
+func WithEvictLeader() TransferOption {
+	return evictLeaderOption{}
+}
+
+type evictLeaderOption struct{}
+
+func (o evictLeaderOption) applyTransfer(options *transferOptions) {
+	options.evict = true
+}
@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package election
+
+import (
+	"context"
+	"fmt"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// defaultGetTermsParallelism is the default maximum number of terms fetched concurrently by GetTerms
+const defaultGetTermsParallelism = 10
+
+// GetTermsOption is an option for the GetTerms function
+type GetTermsOption interface {
+	applyGetTerms(options *getTermsOptions)
+}
+
+// getTermsOptions is a set of GetTerms options
+type getTermsOptions struct {
+	parallelism int
+}
+
+// WithParallelism sets the maximum number of terms to fetch concurrently
+func WithParallelism(parallelism int) GetTermsOption {
+	return parallelismOption{parallelism: parallelism}
+}
+
+type parallelismOption struct {
+	parallelism int
+}
+
+func (o parallelismOption) applyGetTerms(options *getTermsOptions) {
+	options.parallelism = o.parallelism
+}
+
+// ElectionErrors is the set of errors that occurred for individual elections, keyed by election name
+type ElectionErrors map[string]error
+
+func (e ElectionErrors) Error() string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	messages := make([]string, len(names))
+	for i, name := range names {
+		messages[i] = fmt.Sprintf("%s: %v", name, e[name])
+	}
+	return strings.Join(messages, "; ")
+}
+
+// GetTerms gets the current terms of the elections with the given names
+// Terms are fetched concurrently, with at most the number of concurrent requests set by the WithParallelism
+// option. Elections are got from the given client, which creates them if necessary. The returned map contains
+// the terms of the elections that were fetched successfully; if the term of any election cannot be fetched,
+// an ElectionErrors error is returned alongside the map, mapping each failed election to its error.
+func GetTerms(ctx context.Context, client Client, names []string, opts ...GetTermsOption) (map[string]*Term, error) {
+	options := getTermsOptions{
+		parallelism: defaultGetTermsParallelism,
+	}
+	for _, opt := range opts {
+		opt.applyGetTerms(&options)
+	}
+	if options.parallelism <= 0 {
+		return nil, errors.NewInvalid("parallelism must be positive")
+	}
+
+	terms := make(map[string]*Term)
+	errs := make(ElectionErrors)
+	mu := &sync.Mutex{}
+	sem := make(chan struct{}, options.parallelism)
+	wg := &sync.WaitGroup{}
+	for _, name := range names {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			mu.Lock()
+			errs[name] = errors.From(ctx.Err())
+			mu.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func(name string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			term, err := getTerm(ctx, client, name)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[name] = err
+			} else {
+				terms[name] = term
+			}
+		}(name)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return terms, errs
+	}
+	return terms, nil
+}
+
+// getTerm gets the current term of the election with the given name
+func getTerm(ctx context.Context, client Client, name string) (*Term, error) {
+	election, err := client.GetElection(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return election.GetTerm(ctx)
+}
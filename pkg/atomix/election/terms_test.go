@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package election
+
+import (
+	"context"
+	api "github.com/atomix/atomix-api/go/atomix/primitive/election"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testTermClient is an election client that returns a fixed term or error
+type testTermClient struct {
+	api.LeaderElectionServiceClient
+	client *testGroupClient
+	term   api.Term
+	err    error
+}
+
+func (c *testTermClient) GetTerm(ctx context.Context, request *api.GetTermRequest, opts ...grpc.CallOption) (*api.GetTermResponse, error) {
+	c.client.begin()
+	defer c.client.end()
+	time.Sleep(10 * time.Millisecond)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &api.GetTermResponse{
+		Term: c.term,
+	}, nil
+}
+
+// testGroupClient is an election Client that gets elections with fixed terms and tracks concurrent requests
+type testGroupClient struct {
+	terms     map[string]api.Term
+	errs      map[string]error
+	getErrs   map[string]error
+	mu        sync.Mutex
+	active    int
+	maxActive int
+}
+
+func (c *testGroupClient) begin() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active++
+	if c.active > c.maxActive {
+		c.maxActive = c.active
+	}
+}
+
+func (c *testGroupClient) end() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active--
+}
+
+func (c *testGroupClient) GetElection(ctx context.Context, name string, opts ...primitive.Option) (Election, error) {
+	if err, ok := c.getErrs[name]; ok {
+		return nil, err
+	}
+	return &election{
+		Client: primitive.NewClient(Type, name, nil),
+		client: &testTermClient{
+			client: c,
+			term:   c.terms[name],
+			err:    c.errs[name],
+		},
+	}, nil
+}
+
+func TestGetTerms(t *testing.T) {
+	client := &testGroupClient{
+		terms: map[string]api.Term{
+			"a": {Leader: "foo", Candidates: []string{"foo", "bar"}},
+			"b": {Leader: "bar", Candidates: []string{"bar"}},
+			"c": {Leader: "baz", Candidates: []string{"baz"}},
+			"d": {},
+		},
+	}
+
+	// Terms are fetched for all elections with bounded parallelism
+	terms, err := GetTerms(context.Background(), client, []string{"a", "b", "c", "d"}, WithParallelism(2))
+	assert.NoError(t, err)
+	assert.Len(t, terms, 4)
+	assert.Equal(t, "foo", terms["a"].Leader)
+	assert.Equal(t, []string{"foo", "bar"}, terms["a"].Candidates)
+	assert.Equal(t, "bar", terms["b"].Leader)
+	assert.Equal(t, "baz", terms["c"].Leader)
+	assert.Equal(t, "", terms["d"].Leader)
+	assert.Equal(t, 2, client.maxActive)
+
+	_, err = GetTerms(context.Background(), client, []string{"a"}, WithParallelism(0))
+	assert.True(t, errors.IsInvalid(err))
+}
+
+func TestGetTermsErrors(t *testing.T) {
+	client := &testGroupClient{
+		terms: map[string]api.Term{
+			"a": {Leader: "foo"},
+		},
+		errs: map[string]error{
+			"b": status.Error(codes.Unavailable, "unavailable"),
+		},
+		getErrs: map[string]error{
+			"missing": errors.NewNotFound("not found"),
+		},
+	}
+
+	// Terms that were fetched are returned alongside the errors of the elections that failed
+	terms, err := GetTerms(context.Background(), client, []string{"a", "b", "missing"})
+	assert.Error(t, err)
+	assert.Len(t, terms, 1)
+	assert.Equal(t, "foo", terms["a"].Leader)
+
+	var electionErrs ElectionErrors
+	assert.True(t, errors.As(err, &electionErrs))
+	assert.Len(t, electionErrs, 2)
+	assert.True(t, errors.IsUnavailable(electionErrs["b"]))
+	assert.True(t, errors.IsNotFound(electionErrs["missing"]))
+	assert.Equal(t, "b: unavailable; missing: not found", err.Error())
+}
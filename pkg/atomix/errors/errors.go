@@ -0,0 +1,348 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Type is the type of a primitive error
+type Type = errors.Type
+
+const (
+	// Unknown is an unknown error type
+	Unknown = errors.Unknown
+	// Canceled indicates a request context was canceled
+	Canceled = errors.Canceled
+	// NotFound indicates a resource was not found
+	NotFound = errors.NotFound
+	// AlreadyExists indicates a resource already exists
+	AlreadyExists = errors.AlreadyExists
+	// Unauthorized indicates access to a resource is not authorized
+	Unauthorized = errors.Unauthorized
+	// Forbidden indicates the operation requested to be performed on a resource is forbidden
+	Forbidden = errors.Forbidden
+	// Conflict indicates a conflict occurred during concurrent modifications to a resource
+	Conflict = errors.Conflict
+	// Invalid indicates a message or request is invalid
+	Invalid = errors.Invalid
+	// Unavailable indicates a service is not available
+	Unavailable = errors.Unavailable
+	// NotSupported indicates a method is not supported
+	NotSupported = errors.NotSupported
+	// Timeout indicates a request timed out
+	Timeout = errors.Timeout
+	// Internal indicates an unexpected internal error occurred
+	Internal = errors.Internal
+)
+
+var (
+	// ErrCanceled is matched by errors for canceled requests
+	ErrCanceled = newSentinel("canceled")
+	// ErrNotFound is matched by errors for resources that were not found
+	ErrNotFound = newSentinel("not found")
+	// ErrAlreadyExists is matched by errors for resources that already exist
+	ErrAlreadyExists = newSentinel("already exists")
+	// ErrConflict is matched by errors for failed preconditions and conflicting modifications
+	ErrConflict = newSentinel("conflict")
+	// ErrInvalidArgument is matched by errors for invalid requests
+	ErrInvalidArgument = newSentinel("invalid argument")
+	// ErrUnavailable is matched by errors for unavailable services
+	ErrUnavailable = newSentinel("unavailable")
+	// ErrNotSupported is matched by errors for unsupported operations
+	ErrNotSupported = newSentinel("not supported")
+	// ErrTimeout is matched by errors for requests that timed out
+	ErrTimeout = newSentinel("timeout")
+	// ErrClosed is matched by errors for requests made on a closed client
+	// Errors matching ErrClosed also match ErrCanceled.
+	ErrClosed = newSentinel("closed")
+	// ErrPanic is matched by errors for panics recovered from user callbacks
+	ErrPanic = newSentinel("panic")
+	// ErrInvalidOption is matched by errors for invalid option values
+	// Errors matching ErrInvalidOption also match ErrInvalidArgument.
+	ErrInvalidOption = newSentinel("invalid option")
+)
+
+func newSentinel(msg string) error {
+	return stderrors.New(msg)
+}
+
+// sentinels maps error types to the sentinel errors they match
+var sentinels = map[Type]error{
+	Canceled:      ErrCanceled,
+	NotFound:      ErrNotFound,
+	AlreadyExists: ErrAlreadyExists,
+	Conflict:      ErrConflict,
+	Invalid:       ErrInvalidArgument,
+	Unavailable:   ErrUnavailable,
+	NotSupported:  ErrNotSupported,
+	Timeout:       ErrTimeout,
+}
+
+// TypedError is the framework's typed error, which underlies the errors returned by primitives
+type TypedError = errors.TypedError
+
+// Error is the error returned by primitives
+// Error wraps the framework's typed error and matches the sentinel error for its type, so errors returned by
+// primitives can be matched with the standard library errors.Is, e.g. errors.Is(err, ErrNotFound). The typed
+// error can be retrieved with errors.As.
+type Error struct {
+	err *errors.TypedError
+}
+
+func (e *Error) Error() string {
+	return e.err.Error()
+}
+
+// Type returns the type of the error
+func (e *Error) Type() Type {
+	return e.err.Type
+}
+
+// Unwrap returns the underlying typed error
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Is returns whether the error matches the given sentinel error
+func (e *Error) Is(target error) bool {
+	return matches(e.err, target)
+}
+
+// wrap wraps the given typed error
+func wrap(err *errors.TypedError) *Error {
+	return &Error{err: err}
+}
+
+// OptionError is an Invalid error for an invalid option value
+type OptionError struct {
+	err *Error
+}
+
+func (e *OptionError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying Invalid error
+func (e *OptionError) Unwrap() error {
+	return e.err
+}
+
+// Is returns whether the error matches the given sentinel error
+func (e *OptionError) Is(target error) bool {
+	return target == ErrInvalidOption
+}
+
+// New creates a new primitive error of the given type
+func New(t Type, msg string, args ...interface{}) error {
+	return wrap(errors.New(t, msg, args...).(*errors.TypedError))
+}
+
+// PanicError is an error converted from a panic recovered from a user callback
+type PanicError struct {
+	// Value is the value passed to panic
+	Value interface{}
+	// Stack is the stack trace of the panicking goroutine
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("callback panicked: %v", e.Value)
+}
+
+// Is returns whether the error matches the given sentinel error
+func (e *PanicError) Is(target error) bool {
+	return target == ErrPanic
+}
+
+// NewPanic returns a new PanicError for the given recovered value and stack trace
+func NewPanic(value interface{}, stack []byte) error {
+	return &PanicError{
+		Value: value,
+		Stack: stack,
+	}
+}
+
+// NewCanceled returns a new Canceled error
+func NewCanceled(msg string, args ...interface{}) error {
+	return New(Canceled, msg, args...)
+}
+
+// NewNotFound returns a new NotFound error
+func NewNotFound(msg string, args ...interface{}) error {
+	return New(NotFound, msg, args...)
+}
+
+// NewAlreadyExists returns a new AlreadyExists error
+func NewAlreadyExists(msg string, args ...interface{}) error {
+	return New(AlreadyExists, msg, args...)
+}
+
+// NewConflict returns a new Conflict error
+func NewConflict(msg string, args ...interface{}) error {
+	return New(Conflict, msg, args...)
+}
+
+// NewInvalid returns a new Invalid error
+func NewInvalid(msg string, args ...interface{}) error {
+	return New(Invalid, msg, args...)
+}
+
+// NewInvalidOption returns a new Invalid error for an invalid option value
+func NewInvalidOption(msg string, args ...interface{}) error {
+	return &OptionError{
+		err: New(Invalid, msg, args...).(*Error),
+	}
+}
+
+// NewUnavailable returns a new Unavailable error
+func NewUnavailable(msg string, args ...interface{}) error {
+	return New(Unavailable, msg, args...)
+}
+
+// NewNotSupported returns a new NotSupported error
+func NewNotSupported(msg string, args ...interface{}) error {
+	return New(NotSupported, msg, args...)
+}
+
+// NewTimeout returns a new Timeout error
+func NewTimeout(msg string, args ...interface{}) error {
+	return New(Timeout, msg, args...)
+}
+
+// From converts the given gRPC, context or typed error to a primitive error
+// Errors that are already primitive errors, and errors that cannot be converted, are returned unchanged.
+func From(err error) error {
+	if err == nil {
+		return nil
+	}
+	var primitiveErr *Error
+	if stderrors.As(err, &primitiveErr) {
+		return err
+	}
+	var panicErr *PanicError
+	if stderrors.As(err, &panicErr) {
+		return err
+	}
+	if typed, ok := errors.From(err).(*errors.TypedError); ok {
+		return wrap(typed)
+	}
+	return err
+}
+
+// Code returns the gRPC status code corresponding to the given error
+// Errors that are neither primitive errors nor gRPC status errors have the Unknown code.
+func Code(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+	var typed *errors.TypedError
+	if stderrors.As(err, &typed) {
+		return status.Code(errors.Proto(typed))
+	}
+	return status.Code(err)
+}
+
+// isClientConnClosing returns whether the given error indicates the gRPC client connection was closed
+func isClientConnClosing(err *errors.TypedError) bool {
+	closing := status.Convert(grpc.ErrClientConnClosing)
+	return err.Type == Canceled && err.Message == closing.Message()
+}
+
+// TypeOf returns the type of the given error
+func TypeOf(err error) Type {
+	var typed *errors.TypedError
+	if stderrors.As(err, &typed) {
+		return typed.Type
+	}
+	return errors.Unknown
+}
+
+// IsCanceled checks whether the given error matches ErrCanceled
+func IsCanceled(err error) bool {
+	return Is(err, ErrCanceled)
+}
+
+// IsNotFound checks whether the given error matches ErrNotFound
+func IsNotFound(err error) bool {
+	return Is(err, ErrNotFound)
+}
+
+// IsAlreadyExists checks whether the given error matches ErrAlreadyExists
+func IsAlreadyExists(err error) bool {
+	return Is(err, ErrAlreadyExists)
+}
+
+// IsConflict checks whether the given error matches ErrConflict
+func IsConflict(err error) bool {
+	return Is(err, ErrConflict)
+}
+
+// IsInvalid checks whether the given error matches ErrInvalidArgument
+func IsInvalid(err error) bool {
+	return Is(err, ErrInvalidArgument)
+}
+
+// IsInvalidOption checks whether the given error matches ErrInvalidOption
+func IsInvalidOption(err error) bool {
+	return Is(err, ErrInvalidOption)
+}
+
+// IsUnavailable checks whether the given error matches ErrUnavailable
+func IsUnavailable(err error) bool {
+	return Is(err, ErrUnavailable)
+}
+
+// IsNotSupported checks whether the given error matches ErrNotSupported
+func IsNotSupported(err error) bool {
+	return Is(err, ErrNotSupported)
+}
+
+// IsTimeout checks whether the given error matches ErrTimeout
+func IsTimeout(err error) bool {
+	return Is(err, ErrTimeout)
+}
+
+// IsClosed checks whether the given error matches ErrClosed
+func IsClosed(err error) bool {
+	return Is(err, ErrClosed)
+}
+
+// IsPanic checks whether the given error matches ErrPanic
+func IsPanic(err error) bool {
+	return Is(err, ErrPanic)
+}
+
+// Is reports whether any error in err's chain matches target
+// Is is equivalent to the standard library errors.Is, except that a framework typed error that has not been
+// converted with From also matches the sentinel error for its type.
+func Is(err, target error) bool {
+	if stderrors.Is(err, target) {
+		return true
+	}
+	var typed *errors.TypedError
+	return stderrors.As(err, &typed) && matches(typed, target)
+}
+
+// matches returns whether the given typed error matches the given sentinel error
+// A Canceled error for a closed client connection also matches ErrClosed.
+func matches(err *errors.TypedError, target error) bool {
+	if target == ErrClosed {
+		return isClientConnClosing(err)
+	}
+	sentinel, ok := sentinels[err.Type]
+	return ok && target == sentinel
+}
+
+// As finds the first error in err's chain that matches target, and if so, sets target to that error value
+// As is equivalent to the standard library errors.As.
+func As(err error, target interface{}) bool {
+	return stderrors.As(err, target)
+}
@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestFromStatus(t *testing.T) {
+	tests := []struct {
+		code     codes.Code
+		sentinel error
+	}{
+		{codes.Canceled, ErrCanceled},
+		{codes.NotFound, ErrNotFound},
+		{codes.AlreadyExists, ErrAlreadyExists},
+		{codes.FailedPrecondition, ErrConflict},
+		{codes.InvalidArgument, ErrInvalidArgument},
+		{codes.Unavailable, ErrUnavailable},
+		{codes.Unimplemented, ErrNotSupported},
+		{codes.DeadlineExceeded, ErrTimeout},
+	}
+	for _, test := range tests {
+		err := From(status.Error(test.code, "foo"))
+		assert.True(t, Is(err, test.sentinel), test.code.String())
+		assert.False(t, Is(err, ErrClosed), test.code.String())
+		assert.Equal(t, "foo", err.Error())
+
+		wrapped := fmt.Errorf("bar: %w", err)
+		assert.True(t, Is(wrapped, test.sentinel), test.code.String())
+	}
+
+	assert.True(t, IsNotFound(From(status.Error(codes.NotFound, "foo"))))
+	assert.False(t, IsNotFound(From(status.Error(codes.AlreadyExists, "foo"))))
+	assert.True(t, IsTimeout(From(context.DeadlineExceeded)))
+	assert.True(t, IsCanceled(From(context.Canceled)))
+	assert.Nil(t, From(nil))
+}
+
+func TestFromClosed(t *testing.T) {
+	err := From(grpc.ErrClientConnClosing)
+	assert.True(t, IsClosed(err))
+	assert.True(t, IsCanceled(err))
+	assert.True(t, Is(err, ErrClosed))
+
+	assert.False(t, IsClosed(From(status.Error(codes.Canceled, "foo"))))
+	assert.False(t, IsClosed(NewCanceled("foo")))
+}
+
+func TestErrorAs(t *testing.T) {
+	err := fmt.Errorf("bar: %w", NewConflict("foo %d", 1))
+
+	var typed *TypedError
+	assert.True(t, stderrors.As(err, &typed))
+	assert.Equal(t, Conflict, typed.Type)
+	assert.Equal(t, "foo 1", typed.Error())
+
+	assert.Equal(t, Conflict, TypeOf(err))
+	assert.Equal(t, Unknown, TypeOf(stderrors.New("foo")))
+}
+
+func TestIsAs(t *testing.T) {
+	err := fmt.Errorf("bar: %w", From(status.Error(codes.NotFound, "foo")))
+	assert.True(t, Is(err, ErrNotFound))
+	assert.False(t, Is(err, ErrConflict))
+
+	var typed *TypedError
+	assert.True(t, As(err, &typed))
+	assert.Equal(t, NotFound, typed.Type)
+}
+
+func TestStandardIs(t *testing.T) {
+	// Primitive errors match the sentinel errors with the standard library errors.Is
+	err := fmt.Errorf("bar: %w", From(status.Error(codes.NotFound, "foo")))
+	assert.True(t, stderrors.Is(err, ErrNotFound))
+	assert.False(t, stderrors.Is(err, ErrConflict))
+	assert.True(t, stderrors.Is(NewConflict("foo"), ErrConflict))
+	assert.True(t, stderrors.Is(From(grpc.ErrClientConnClosing), ErrClosed))
+	assert.True(t, stderrors.Is(From(grpc.ErrClientConnClosing), ErrCanceled))
+	assert.True(t, stderrors.Is(NewInvalidOption("foo"), ErrInvalidArgument))
+
+	// Converting a primitive error again leaves it unchanged
+	primitiveErr := NewUnavailable("foo")
+	assert.Equal(t, primitiveErr, From(primitiveErr))
+
+	// Framework errors that have not been converted match the sentinel errors with Is
+	assert.True(t, IsNotFound(errors.NewNotFound("foo")))
+	assert.True(t, Is(errors.NewTimeout("foo"), ErrTimeout))
+	assert.True(t, stderrors.Is(From(errors.NewTimeout("foo")), ErrTimeout))
+}
+
+func TestCode(t *testing.T) {
+	assert.Equal(t, codes.OK, Code(nil))
+	assert.Equal(t, codes.Unavailable, Code(From(status.Error(codes.Unavailable, "foo"))))
+	assert.Equal(t, codes.InvalidArgument, Code(fmt.Errorf("bar: %w", NewInvalid("foo"))))
+	assert.Equal(t, codes.DeadlineExceeded, Code(From(context.DeadlineExceeded)))
+	assert.Equal(t, codes.PermissionDenied, Code(status.Error(codes.PermissionDenied, "foo")))
+	assert.Equal(t, codes.Unknown, Code(stderrors.New("foo")))
+}
+
+func TestPanic(t *testing.T) {
+	err := fmt.Errorf("bar: %w", NewPanic("foo", []byte("stack")))
+	assert.True(t, IsPanic(err))
+	assert.True(t, Is(err, ErrPanic))
+	assert.False(t, IsCanceled(err))
+	assert.Equal(t, "bar: callback panicked: foo", err.Error())
+
+	var e *PanicError
+	assert.True(t, As(err, &e))
+	assert.Equal(t, "foo", e.Value)
+	assert.Equal(t, []byte("stack"), e.Stack)
+
+	assert.False(t, IsPanic(NewConflict("foo")))
+}
+
+func TestInvalidOption(t *testing.T) {
+	err := fmt.Errorf("bar: %w", NewInvalidOption("foo %d", 1))
+	assert.True(t, IsInvalidOption(err))
+	assert.True(t, IsInvalid(err))
+	assert.True(t, Is(err, ErrInvalidOption))
+	assert.Equal(t, "bar: foo 1", err.Error())
+
+	assert.False(t, IsInvalidOption(NewInvalid("foo")))
+	assert.False(t, IsInvalidOption(From(status.Error(codes.InvalidArgument, "foo"))))
+}
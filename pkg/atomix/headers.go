@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package atomix
+
+import (
+	"context"
+	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	"google.golang.org/grpc"
+	"sync"
+)
+
+// headerCaptureKey is the context key for a HeaderCapture
+type headerCaptureKey struct{}
+
+// HeaderCapture records the response headers of requests made with a capturing context
+type HeaderCapture struct {
+	headers *primitiveapi.ResponseHeaders
+	mu      sync.RWMutex
+}
+
+// Headers returns the response headers of the most recent request that completed successfully
+// The returned bool is false if no request made with the capturing context has completed successfully.
+func (c *HeaderCapture) Headers() (primitiveapi.ResponseHeaders, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.headers == nil {
+		return primitiveapi.ResponseHeaders{}, false
+	}
+	return *c.headers, true
+}
+
+func (c *HeaderCapture) capture(headers primitiveapi.ResponseHeaders) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.headers = &headers
+}
+
+// WithHeaderCapture returns a context that captures the response headers of requests made with it
+// Requests made through primitives created by a Client with the returned context record their response
+// headers in the returned HeaderCapture, which can be inspected once the request returns. Streaming
+// requests, e.g. watches, are not captured.
+func WithHeaderCapture(ctx context.Context) (context.Context, *HeaderCapture) {
+	capture := &HeaderCapture{}
+	return context.WithValue(ctx, headerCaptureKey{}, capture), capture
+}
+
+// headerResponse is a response carrying response headers
+type headerResponse interface {
+	GetHeaders() primitiveapi.ResponseHeaders
+}
+
+// headerCaptureInterceptor returns an interceptor that records response headers for a capturing context
+func headerCaptureInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+			return err
+		}
+		if capture, ok := ctx.Value(headerCaptureKey{}).(*HeaderCapture); ok {
+			if response, ok := reply.(headerResponse); ok {
+				capture.capture(response.GetHeaders())
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package atomix
+
+import (
+	"context"
+	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	mapapi "github.com/atomix/atomix-api/go/atomix/primitive/map"
+	metaapi "github.com/atomix/atomix-api/go/atomix/primitive/meta"
+	_map "github.com/atomix/atomix-go-client/pkg/atomix/map"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"net"
+	"sync"
+	"testing"
+)
+
+// testHeaderMapServer is a map server that returns a logical timestamp in the headers of each response
+type testHeaderMapServer struct {
+	mapapi.UnimplementedMapServiceServer
+	time metaapi.LogicalTime
+	mu   sync.Mutex
+}
+
+func (s *testHeaderMapServer) Get(ctx context.Context, request *mapapi.GetRequest) (*mapapi.GetResponse, error) {
+	s.mu.Lock()
+	s.time++
+	headers := primitiveapi.ResponseHeaders{
+		Timestamp: &metaapi.Timestamp{
+			Timestamp: &metaapi.Timestamp_LogicalTimestamp{
+				LogicalTimestamp: &metaapi.LogicalTimestamp{
+					Time: s.time,
+				},
+			},
+		},
+	}
+	s.mu.Unlock()
+	return &mapapi.GetResponse{
+		Headers: headers,
+		Entry: mapapi.Entry{
+			Key: mapapi.Key{
+				Key: request.Key,
+			},
+			Value: &mapapi.Value{},
+		},
+	}, nil
+}
+
+func TestHeaderCapture(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := grpc.NewServer()
+	primitiveapi.RegisterPrimitiveServer(server, &testPrimitiveServer{})
+	mapapi.RegisterMapServiceServer(server, &testHeaderMapServer{})
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	defer server.Stop()
+
+	client := NewClient().(*atomixClient)
+	conn, err := grpc.Dial(lis.Addr().String(), client.getPrimitiveDialOptions()...)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	m, err := _map.New(context.Background(), "TestHeaderCapture", conn)
+	assert.NoError(t, err)
+
+	ctx, capture := WithHeaderCapture(context.Background())
+	_, ok := capture.Headers()
+	assert.False(t, ok)
+
+	_, err = m.Get(ctx, "foo")
+	assert.NoError(t, err)
+	headers, ok := capture.Headers()
+	assert.True(t, ok)
+	assert.Equal(t, metaapi.LogicalTime(1), headers.Timestamp.GetLogicalTimestamp().Time)
+
+	// Requests made without the capturing context are not captured
+	_, err = m.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	headers, ok = capture.Headers()
+	assert.True(t, ok)
+	assert.Equal(t, metaapi.LogicalTime(1), headers.Timestamp.GetLogicalTimestamp().Time)
+
+	// The capture holds the headers of the most recent request
+	_, err = m.Get(ctx, "foo")
+	assert.NoError(t, err)
+	headers, ok = capture.Headers()
+	assert.True(t, ok)
+	assert.Equal(t, metaapi.LogicalTime(3), headers.Timestamp.GetLogicalTimestamp().Time)
+}
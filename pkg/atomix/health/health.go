@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package health
+
+import (
+	"context"
+	"fmt"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultTimeout = 5 * time.Second
+
+// Checker is a component whose health can be checked
+// All primitives implement Checker by pinging the primitive's service.
+type Checker interface {
+	// Ping returns an error if the component is unhealthy
+	Ping(ctx context.Context) error
+}
+
+// Option is a health option
+type Option interface {
+	apply(*options)
+}
+
+type options struct {
+	timeout time.Duration
+}
+
+// WithTimeout sets the timeout for each health check
+func WithTimeout(timeout time.Duration) Option {
+	return timeoutOption{timeout: timeout}
+}
+
+type timeoutOption struct {
+	timeout time.Duration
+}
+
+func (o timeoutOption) apply(options *options) {
+	options.timeout = o.timeout
+}
+
+// NewHealth creates a new Health aggregating the health of registered components
+func NewHealth(opts ...Option) *Health {
+	options := options{
+		timeout: defaultTimeout,
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return &Health{
+		options:  options,
+		checkers: make(map[string]Checker),
+	}
+}
+
+// Health aggregates the health of a set of registered components, e.g. primitives
+// The aggregate is healthy only if all registered components are healthy.
+type Health struct {
+	options  options
+	checkers map[string]Checker
+	mu       sync.RWMutex
+}
+
+// Register registers a component to be checked under the given name
+func (h *Health) Register(name string, checker Checker) {
+	h.mu.Lock()
+	h.checkers[name] = checker
+	h.mu.Unlock()
+}
+
+// Unregister unregisters the named component
+func (h *Health) Unregister(name string) {
+	h.mu.Lock()
+	delete(h.checkers, name)
+	h.mu.Unlock()
+}
+
+// Check checks the health of all registered components concurrently
+// If any component is unhealthy, an Unavailable error naming the unhealthy components is returned.
+func (h *Health) Check(ctx context.Context) error {
+	h.mu.RLock()
+	checkers := make(map[string]Checker, len(h.checkers))
+	for name, checker := range h.checkers {
+		checkers[name] = checker
+	}
+	h.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, h.options.timeout)
+	defer cancel()
+
+	wg := &sync.WaitGroup{}
+	mu := &sync.Mutex{}
+	var failures []string
+	for name, checker := range checkers {
+		wg.Add(1)
+		go func(name string, checker Checker) {
+			defer wg.Done()
+			if err := checker.Ping(ctx); err != nil {
+				mu.Lock()
+				failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+				mu.Unlock()
+			}
+		}(name, checker)
+	}
+	wg.Wait()
+
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return errors.NewUnavailable("unhealthy: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// Handler returns an HTTP handler reporting the aggregate health
+// The handler responds with 200 if all registered components are healthy and 503 otherwise.
+func (h *Health) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := h.Check(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("OK"))
+	})
+}
+
+// Server returns a gRPC health server reporting the aggregate health
+// The server can be registered with grpc_health_v1.RegisterHealthServer.
+func (h *Health) Server() grpc_health_v1.HealthServer {
+	return &healthServer{
+		health: h,
+	}
+}
+
+// healthServer is a gRPC health server backed by a Health
+type healthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	health *Health
+}
+
+func (s *healthServer) Check(ctx context.Context, request *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if err := s.health.Check(ctx); err != nil {
+		return &grpc_health_v1.HealthCheckResponse{
+			Status: grpc_health_v1.HealthCheckResponse_NOT_SERVING,
+		}, nil
+	}
+	return &grpc_health_v1.HealthCheckResponse{
+		Status: grpc_health_v1.HealthCheckResponse_SERVING,
+	}, nil
+}
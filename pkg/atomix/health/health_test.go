@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package health
+
+import (
+	"context"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// testChecker is a checker returning a fixed error
+type testChecker struct {
+	err error
+}
+
+func (c *testChecker) Ping(ctx context.Context) error {
+	return c.err
+}
+
+func TestHealth(t *testing.T) {
+	health := NewHealth()
+	assert.NoError(t, health.Check(context.Background()))
+
+	foo := &testChecker{}
+	bar := &testChecker{}
+	health.Register("foo", foo)
+	health.Register("bar", bar)
+	assert.NoError(t, health.Check(context.Background()))
+
+	recorder := httptest.NewRecorder()
+	health.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	response, err := health.Server().Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, response.Status)
+
+	// The aggregate is unhealthy if any registered component is unhealthy
+	bar.err = errors.NewUnavailable("session expired")
+	err = health.Check(context.Background())
+	assert.True(t, errors.IsUnavailable(err))
+	assert.Contains(t, err.Error(), "bar")
+	assert.NotContains(t, err.Error(), "foo")
+
+	recorder = httptest.NewRecorder()
+	health.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	response, err = health.Server().Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, response.Status)
+
+	// Unregistered components are no longer checked
+	health.Unregister("bar")
+	assert.NoError(t, health.Check(context.Background()))
+}
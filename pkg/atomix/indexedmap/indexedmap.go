@@ -9,12 +9,16 @@ import (
 	"fmt"
 	api "github.com/atomix/atomix-api/go/atomix/primitive/indexedmap"
 	metaapi "github.com/atomix/atomix-api/go/atomix/primitive/meta"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
-	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"github.com/atomix/atomix-go-client/pkg/atomix/util"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/time"
 	"google.golang.org/grpc"
 	"io"
+	"sort"
+	"strings"
 )
 
 // Type is the indexed map type
@@ -50,6 +54,13 @@ type IndexedMap interface {
 	// Get gets the value of the given key
 	Get(ctx context.Context, key string, opts ...GetOption) (*Entry, error)
 
+	// UpdateAll updates the values at the given indexes, returning the number of updates applied
+	// Each update is applied only if the revision of the entry at its index matches the update's expected
+	// revision. Updates are applied one at a time in index order and are not atomic as a group. If any update
+	// is not applied, an IndexErrors error is returned mapping each failed index to its error; updates whose
+	// revision did not match fail with a Conflict error.
+	UpdateAll(ctx context.Context, updates map[Index]Update) (int, error)
+
 	// GetIndex gets the entry at the given index
 	GetIndex(ctx context.Context, index Index, opts ...GetOption) (*Entry, error)
 
@@ -83,6 +94,12 @@ type IndexedMap interface {
 	// RemoveIndex removes an index from the map
 	RemoveIndex(ctx context.Context, index Index, opts ...RemoveOption) (*Entry, error)
 
+	// RemoveRange removes all entries with indexes in the range [start, end), returning the number of
+	// entries removed
+	// The API has no range delete, so entries are removed one at a time. The removal is not atomic: if an
+	// error occurs, entries removed before the error remain removed.
+	RemoveRange(ctx context.Context, start, end Index) (int, error)
+
 	// Len returns the number of entries in the map
 	Len(ctx context.Context) (int, error)
 
@@ -91,7 +108,8 @@ type IndexedMap interface {
 
 	// Entries lists the entries in the map
 	// This is a non-blocking method. If the method returns without error, key/value paids will be pushed on to the
-	// given channel and the channel will be closed once all entries have been read from the map.
+	// given channel and the channel will be closed once all entries have been read from the map. If the context is
+	// cancelled, the stream is closed and the channel is closed without waiting for the remaining entries to be read.
 	Entries(ctx context.Context, ch chan<- Entry) error
 
 	// Watch watches the map for changes
@@ -119,6 +137,33 @@ func (kv Entry) String() string {
 	return fmt.Sprintf("key: %s\nvalue: %s", kv.Key, string(kv.Value))
 }
 
+// Update is a conditional update of the value at an index
+type Update struct {
+	// Revision is the expected revision of the entry at the index
+	Revision meta.Revision
+
+	// Value is the value to set
+	Value []byte
+}
+
+// IndexErrors is an error returned by operations on multiple indexes, mapping each failed index to its error
+type IndexErrors map[Index]error
+
+func (e IndexErrors) Error() string {
+	indexes := make([]Index, 0, len(e))
+	for index := range e {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexes[i] < indexes[j]
+	})
+	messages := make([]string, len(indexes))
+	for i, index := range indexes {
+		messages[i] = fmt.Sprintf("%d: %v", index, e[index])
+	}
+	return strings.Join(messages, "; ")
+}
+
 // EventType is the type of a map event
 type EventType string
 
@@ -143,6 +188,9 @@ type Event struct {
 
 	// Entry is the event entry
 	Entry Entry
+
+	// Timestamp is the server time at which the entry changed, as read by primitive.GetTimestamp
+	Timestamp time.Timestamp
 }
 
 // New creates a new IndexedMap primitive
@@ -256,6 +304,40 @@ func (m *indexedMap) Set(ctx context.Context, index Index, key string, value []b
 	return newEntry(response.Entry), nil
 }
 
+func (m *indexedMap) UpdateAll(ctx context.Context, updates map[Index]Update) (int, error) {
+	indexes := make([]Index, 0, len(updates))
+	for index := range updates {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexes[i] < indexes[j]
+	})
+
+	applied := 0
+	errs := make(IndexErrors)
+	for _, index := range indexes {
+		update := updates[index]
+		entry, err := m.GetIndex(ctx, index)
+		if err != nil {
+			errs[index] = err
+			continue
+		}
+		if entry.Revision != update.Revision {
+			errs[index] = errors.NewConflict("revision %d of index %d does not match expected revision %d", entry.Revision, index, update.Revision)
+			continue
+		}
+		if _, err := m.Set(ctx, index, entry.Key, update.Value, IfMatch(entry)); err != nil {
+			errs[index] = err
+			continue
+		}
+		applied++
+	}
+	if len(errs) > 0 {
+		return applied, errs
+	}
+	return applied, nil
+}
+
 func (m *indexedMap) Get(ctx context.Context, key string, opts ...GetOption) (*Entry, error) {
 	request := &api.GetRequest{
 		Headers: m.GetHeaders(),
@@ -266,7 +348,7 @@ func (m *indexedMap) Get(ctx context.Context, key string, opts ...GetOption) (*E
 	for i := range opts {
 		opts[i].beforeGet(request)
 	}
-	response, err := m.client.Get(ctx, request)
+	response, err := m.get(ctx, request, opts...)
 	if err != nil {
 		return nil, errors.From(err)
 	}
@@ -286,7 +368,7 @@ func (m *indexedMap) GetIndex(ctx context.Context, index Index, opts ...GetOptio
 	for i := range opts {
 		opts[i].beforeGet(request)
 	}
-	response, err := m.client.Get(ctx, request)
+	response, err := m.get(ctx, request, opts...)
 	if err != nil {
 		return nil, errors.From(err)
 	}
@@ -296,6 +378,21 @@ func (m *indexedMap) GetIndex(ctx context.Context, index Index, opts ...GetOptio
 	return newEntry(response.Entry), nil
 }
 
+func (m *indexedMap) get(ctx context.Context, request *api.GetRequest, opts ...GetOption) (*api.GetResponse, error) {
+	for i := range opts {
+		if hedging, ok := opts[i].(hedgingOption); ok {
+			response, err := util.Hedge(ctx, hedging.delay, func(ctx context.Context) (interface{}, error) {
+				return m.client.Get(ctx, request)
+			})
+			if err != nil {
+				return nil, err
+			}
+			return response.(*api.GetResponse), nil
+		}
+	}
+	return m.client.Get(ctx, request)
+}
+
 func (m *indexedMap) FirstIndex(ctx context.Context) (Index, error) {
 	request := &api.FirstEntryRequest{
 		Headers: m.GetHeaders(),
@@ -432,6 +529,47 @@ func (m *indexedMap) RemoveIndex(ctx context.Context, index Index, opts ...Remov
 	return newEntry(response.Entry), nil
 }
 
+func (m *indexedMap) RemoveRange(ctx context.Context, start, end Index) (int, error) {
+	if start >= end {
+		return 0, nil
+	}
+
+	var entry *Entry
+	var err error
+	if start == 0 {
+		entry, err = m.FirstEntry(ctx)
+	} else {
+		entry, err = m.GetIndex(ctx, start)
+		if errors.IsNotFound(err) {
+			entry, err = m.NextEntry(ctx, start)
+		}
+	}
+
+	removed := 0
+	for {
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return removed, nil
+			}
+			return removed, err
+		}
+		if entry.Index >= end {
+			return removed, nil
+		}
+
+		// Find the next entry before removing this one to follow the entry's link to its successor
+		next, nextErr := m.NextEntry(ctx, entry.Index)
+		if entry.Index >= start {
+			if _, err := m.RemoveIndex(ctx, entry.Index); err == nil {
+				removed++
+			} else if !errors.IsNotFound(err) {
+				return removed, err
+			}
+		}
+		entry, err = next, nextErr
+	}
+}
+
 func (m *indexedMap) Len(ctx context.Context) (int, error) {
 	request := &api.SizeRequest{
 		Headers: m.GetHeaders(),
@@ -479,7 +617,12 @@ func (m *indexedMap) Entries(ctx context.Context, ch chan<- Entry) error {
 				return
 			}
 
-			ch <- *newEntry(&response.Entry)
+			// Stop delivering entries once the context is done, even if the consumer stops reading
+			select {
+			case ch <- *newEntry(&response.Entry):
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
 	return nil
@@ -498,15 +641,10 @@ func (m *indexedMap) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOp
 		return errors.From(err)
 	}
 
-	openCh := make(chan struct{})
+	handshake := primitive.NewHandshake()
 	go func() {
 		defer close(ch)
-		open := false
-		defer func() {
-			if !open {
-				close(openCh)
-			}
-		}()
+		defer handshake.Open()
 		for {
 			response, err := stream.Recv()
 			if err != nil {
@@ -514,6 +652,10 @@ func (m *indexedMap) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOp
 					return
 				}
 				err = errors.From(err)
+				// A stream that fails before it is opened fails the watch
+				if handshake.Fail(err) {
+					return
+				}
 				if errors.IsCanceled(err) || errors.IsTimeout(err) {
 					return
 				}
@@ -521,10 +663,11 @@ func (m *indexedMap) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOp
 				return
 			}
 
-			if !open {
-				close(openCh)
-				open = true
+			handshake.Open()
+			if response.Event.Type == api.Event_NONE {
+				continue
 			}
+			timestamp := primitive.GetTimestamp(response.Headers)
 
 			for i := range opts {
 				opts[i].afterWatch(response)
@@ -533,32 +676,31 @@ func (m *indexedMap) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOp
 			switch response.Event.Type {
 			case api.Event_INSERT:
 				ch <- Event{
-					Type:  EventInsert,
-					Entry: *newEntry(&response.Event.Entry),
+					Type:      EventInsert,
+					Entry:     *newEntry(&response.Event.Entry),
+					Timestamp: timestamp,
 				}
 			case api.Event_UPDATE:
 				ch <- Event{
-					Type:  EventUpdate,
-					Entry: *newEntry(&response.Event.Entry),
+					Type:      EventUpdate,
+					Entry:     *newEntry(&response.Event.Entry),
+					Timestamp: timestamp,
 				}
 			case api.Event_REMOVE:
 				ch <- Event{
-					Type:  EventRemove,
-					Entry: *newEntry(&response.Event.Entry),
+					Type:      EventRemove,
+					Entry:     *newEntry(&response.Event.Entry),
+					Timestamp: timestamp,
 				}
 			case api.Event_REPLAY:
 				ch <- Event{
-					Type:  EventReplay,
-					Entry: *newEntry(&response.Event.Entry),
+					Type:      EventReplay,
+					Entry:     *newEntry(&response.Event.Entry),
+					Timestamp: timestamp,
 				}
 			}
 		}
 	}()
 
-	select {
-	case <-openCh:
-		return nil
-	case <-ctx.Done():
-		return ctx.Err()
-	}
+	return handshake.Wait(ctx)
 }
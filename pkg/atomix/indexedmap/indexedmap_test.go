@@ -7,11 +7,12 @@ package indexedmap
 import (
 	"context"
 	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-client/pkg/atomix/util/test"
-	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -333,3 +334,201 @@ func TestIndexedMapStreams(t *testing.T) {
 
 	assert.NoError(t, test.Stop())
 }
+
+func TestIndexedMapWatchOpen(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestIndexedMapWatchOpen",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestIndexedMapWatchOpen", conn)
+	assert.NoError(t, err)
+
+	ch := make(chan Event)
+	err = _map.Watch(context.TODO(), ch)
+	assert.NoError(t, err)
+
+	_, err = _map.Put(context.TODO(), "foo", []byte("bar"))
+	assert.NoError(t, err)
+
+	// The open marker completes the handshake and must not be delivered to the consumer
+	event := <-ch
+	assert.Equal(t, EventInsert, event.Type)
+	assert.Equal(t, "foo", event.Entry.Key)
+	assert.Equal(t, "bar", string(event.Entry.Value))
+
+	assert.NoError(t, _map.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+func TestIndexedMapRemoveRange(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestIndexedMapRemoveRange",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestIndexedMapRemoveRange", conn)
+	assert.NoError(t, err)
+
+	for _, key := range []string{"a", "b", "c", "d", "e", "f"} {
+		_, err = _map.Append(context.TODO(), key, []byte(key))
+		assert.NoError(t, err)
+	}
+
+	// An empty range removes nothing
+	removed, err := _map.RemoveRange(context.TODO(), 3, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, removed)
+
+	removed, err = _map.RemoveRange(context.TODO(), 2, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, removed)
+
+	_, err = _map.Get(context.TODO(), "b")
+	assert.True(t, errors.IsNotFound(err))
+	_, err = _map.Get(context.TODO(), "c")
+	assert.True(t, errors.IsNotFound(err))
+	size, err := _map.Len(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 4, size)
+
+	// Indexes already removed are skipped, and the range may extend beyond the last index
+	removed, err = _map.RemoveRange(context.TODO(), 3, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, removed)
+
+	entry, err := _map.LastEntry(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, "a", entry.Key)
+
+	// A range beyond the last index removes nothing
+	removed, err = _map.RemoveRange(context.TODO(), 10, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, removed)
+
+	removed, err = _map.RemoveRange(context.TODO(), 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	size, err = _map.Len(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, size)
+
+	assert.NoError(t, _map.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+func TestIndexedMapUpdateAll(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestIndexedMapUpdateAll",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestIndexedMapUpdateAll", conn)
+	assert.NoError(t, err)
+
+	entries := make(map[string]*Entry)
+	for _, key := range []string{"a", "b", "c"} {
+		entry, err := _map.Append(context.TODO(), key, []byte(key))
+		assert.NoError(t, err)
+		entries[key] = entry
+	}
+
+	// All updates are applied if all revisions match
+	applied, err := _map.UpdateAll(context.TODO(), map[Index]Update{
+		entries["a"].Index: {Revision: entries["a"].Revision, Value: []byte("a1")},
+		entries["b"].Index: {Revision: entries["b"].Revision, Value: []byte("b1")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, applied)
+
+	entry, err := _map.GetIndex(context.TODO(), entries["a"].Index)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", entry.Key)
+	assert.Equal(t, "a1", string(entry.Value))
+	assert.NotEqual(t, entries["a"].Revision, entry.Revision)
+	entries["a"] = entry
+
+	// Only updates whose revisions match are applied
+	applied, err = _map.UpdateAll(context.TODO(), map[Index]Update{
+		entries["a"].Index: {Revision: entries["a"].Revision, Value: []byte("a2")},
+		entries["b"].Index: {Revision: entries["b"].Revision, Value: []byte("b2")},
+		entries["c"].Index: {Revision: entries["c"].Revision, Value: []byte("c2")},
+		Index(100):         {Revision: 1, Value: []byte("d2")},
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 2, applied)
+
+	// The failed updates are reported by index
+	indexErrs, ok := err.(IndexErrors)
+	assert.True(t, ok)
+	assert.Len(t, indexErrs, 2)
+	assert.True(t, errors.IsConflict(indexErrs[entries["b"].Index]))
+	assert.True(t, errors.IsNotFound(indexErrs[Index(100)]))
+
+	entry, err = _map.GetIndex(context.TODO(), entries["b"].Index)
+	assert.NoError(t, err)
+	assert.Equal(t, "b1", string(entry.Value))
+	entry, err = _map.GetIndex(context.TODO(), entries["c"].Index)
+	assert.NoError(t, err)
+	assert.Equal(t, "c2", string(entry.Value))
+
+	assert.NoError(t, _map.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+func TestIndexedMapEntriesCancel(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestIndexedMapEntriesCancel",
+	}
+
+	rsm := test.NewRSMTest()
+	assert.NoError(t, rsm.Start())
+
+	conn, err := rsm.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	m, err := New(context.TODO(), "TestIndexedMapEntriesCancel", conn)
+	assert.NoError(t, err)
+
+	for _, key := range []string{"foo", "bar", "baz", "qux"} {
+		_, err = m.Append(context.Background(), key, []byte(key))
+		assert.NoError(t, err)
+	}
+
+	// Cancelling the context mid-iteration ends the stream and closes the channel although the consumer
+	// has stopped reading
+	check := test.NewLeakCheck()
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan Entry)
+	assert.NoError(t, m.Entries(ctx, ch))
+	<-ch
+	check.AssertCancel(t, cancel, 5*time.Second)
+	_, ok := <-ch
+	assert.False(t, ok)
+
+	assert.NoError(t, m.Close(context.Background()))
+	assert.NoError(t, rsm.Stop())
+}
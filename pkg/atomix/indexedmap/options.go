@@ -9,6 +9,7 @@ import (
 	metaapi "github.com/atomix/atomix-api/go/atomix/primitive/meta"
 	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
+	"time"
 )
 
 // Option is a indexed map option
@@ -99,6 +100,22 @@ type GetOption interface {
 	afterGet(response *api.GetResponse)
 }
 
+// WithHedging returns a Get option that hedges the read against tail latency
+// If the read has not succeeded within the given delay, or fails before it, a second request is issued and the
+// result of whichever request succeeds first is used; the read fails only if both requests fail. Both requests
+// are sent on the primitive's connection, so hedging does not route around an unresponsive connection.
+func WithHedging(delay time.Duration) GetOption {
+	return hedgingOption{delay: delay}
+}
+
+type hedgingOption struct {
+	delay time.Duration
+}
+
+func (o hedgingOption) beforeGet(request *api.GetRequest) {}
+
+func (o hedgingOption) afterGet(response *api.GetResponse) {}
+
 // WatchOption is an option for the Watch method
 type WatchOption interface {
 	beforeWatch(request *api.EventsRequest)
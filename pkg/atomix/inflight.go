@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package atomix
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"sync"
+)
+
+// newSessionInFlightLimiters creates limiters allowing at most the given number of outstanding requests per session
+func newSessionInFlightLimiters(max int) *sessionInFlightLimiters {
+	return &sessionInFlightLimiters{
+		max:      max,
+		limiters: make(map[string]*inFlightLimiter),
+	}
+}
+
+// sessionInFlightLimiters holds the in-flight limiter of each session
+type sessionInFlightLimiters struct {
+	max      int
+	limiters map[string]*inFlightLimiter
+	mu       sync.Mutex
+}
+
+// get returns the limiter for the given session, creating it if necessary
+func (l *sessionInFlightLimiters) get(sessionID string) *inFlightLimiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limiter, ok := l.limiters[sessionID]
+	if !ok {
+		limiter = newInFlightLimiter(l.max)
+		l.limiters[sessionID] = limiter
+	}
+	return limiter
+}
+
+// newInFlightLimiter creates a new limiter allowing at most the given number of outstanding requests
+func newInFlightLimiter(max int) *inFlightLimiter {
+	return &inFlightLimiter{
+		slots: make(chan struct{}, max),
+	}
+}
+
+// inFlightLimiter limits the number of outstanding requests made by a session
+// Requests in excess of the limit wait for a slot to be freed or for their context to be done.
+type inFlightLimiter struct {
+	slots chan struct{}
+}
+
+// acquire waits for a free slot
+func (l *inFlightLimiter) acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a slot
+func (l *inFlightLimiter) release() {
+	<-l.slots
+}
+
+// interceptors returns interceptors that hold a slot for the duration of each request
+// Streams hold a slot only while the stream is being opened, so long-lived streams such as watches do
+// not starve other requests.
+func (l *inFlightLimiter) interceptors() (grpc.UnaryClientInterceptor, grpc.StreamClientInterceptor) {
+	unary := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if err := l.acquire(ctx); err != nil {
+			return err
+		}
+		defer l.release()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	stream := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if err := l.acquire(ctx); err != nil {
+			return nil, err
+		}
+		defer l.release()
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+	return unary, stream
+}
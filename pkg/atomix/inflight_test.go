@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package atomix
+
+import (
+	"context"
+	mapapi "github.com/atomix/atomix-api/go/atomix/primitive/map"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testBlockingMapServer is a map server whose requests block until released
+type testBlockingMapServer struct {
+	mapapi.UnimplementedMapServiceServer
+	release     chan struct{}
+	inFlight    int
+	maxInFlight int
+	mu          sync.Mutex
+}
+
+func (s *testBlockingMapServer) Size(ctx context.Context, request *mapapi.SizeRequest) (*mapapi.SizeResponse, error) {
+	s.mu.Lock()
+	s.inFlight++
+	if s.inFlight > s.maxInFlight {
+		s.maxInFlight = s.inFlight
+	}
+	s.mu.Unlock()
+	<-s.release
+	s.mu.Lock()
+	s.inFlight--
+	s.mu.Unlock()
+	return &mapapi.SizeResponse{}, nil
+}
+
+func TestMaxInFlight(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := grpc.NewServer()
+	mapServer := &testBlockingMapServer{release: make(chan struct{})}
+	mapapi.RegisterMapServiceServer(server, mapServer)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	defer server.Stop()
+
+	client := NewClient(WithMaxInFlight(3)).(*atomixClient)
+	conn, err := grpc.Dial(lis.Addr().String(), client.getPrimitiveDialOptions()...)
+	assert.NoError(t, err)
+	defer conn.Close()
+	mapClient := mapapi.NewMapServiceClient(conn)
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := mapClient.Size(context.Background(), &mapapi.SizeRequest{})
+			assert.NoError(t, err)
+		}()
+	}
+
+	// Requests in excess of the limit wait for a slot
+	time.Sleep(100 * time.Millisecond)
+	mapServer.mu.Lock()
+	assert.Equal(t, 3, mapServer.inFlight)
+	mapServer.mu.Unlock()
+
+	// Queued requests give up once their context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = mapClient.Size(ctx, &mapapi.SizeRequest{})
+	assert.True(t, errors.IsTimeout(errors.From(err)))
+
+	// Queued requests proceed as slots are freed
+	for i := 0; i < 10; i++ {
+		mapServer.release <- struct{}{}
+	}
+	wg.Wait()
+	assert.Equal(t, 3, mapServer.maxInFlight)
+}
+
+func TestMaxInFlightPerSession(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := grpc.NewServer()
+	mapServer := &testBlockingMapServer{release: make(chan struct{})}
+	mapapi.RegisterMapServiceServer(server, mapServer)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	defer server.Stop()
+
+	client := NewClient(WithMaxInFlight(1)).(*atomixClient)
+	conn1, err := grpc.Dial(lis.Addr().String(), client.getSessionDialOptions("session-1")...)
+	assert.NoError(t, err)
+	defer conn1.Close()
+	conn2, err := grpc.Dial(lis.Addr().String(), client.getSessionDialOptions("session-2")...)
+	assert.NoError(t, err)
+	defer conn2.Close()
+
+	done := make(chan error, 2)
+	go func() {
+		_, err := mapapi.NewMapServiceClient(conn1).Size(context.Background(), &mapapi.SizeRequest{})
+		done <- err
+	}()
+
+	// Another connection of the same session shares the session's limit
+	conn3, err := grpc.Dial(lis.Addr().String(), client.getSessionDialOptions("session-1")...)
+	assert.NoError(t, err)
+	defer conn3.Close()
+	time.Sleep(50 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = mapapi.NewMapServiceClient(conn3).Size(ctx, &mapapi.SizeRequest{})
+	assert.True(t, errors.IsTimeout(errors.From(err)))
+
+	// Each session has its own limit
+	go func() {
+		_, err := mapapi.NewMapServiceClient(conn2).Size(context.Background(), &mapapi.SizeRequest{})
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	mapServer.mu.Lock()
+	assert.Equal(t, 2, mapServer.inFlight)
+	mapServer.mu.Unlock()
+
+	mapServer.release <- struct{}{}
+	mapServer.release <- struct{}{}
+	assert.NoError(t, <-done)
+	assert.NoError(t, <-done)
+}
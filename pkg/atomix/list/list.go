@@ -8,9 +8,10 @@ import (
 	"context"
 	"encoding/base64"
 	api "github.com/atomix/atomix-api/go/atomix/primitive/list"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
-	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/time"
 	"google.golang.org/grpc"
 	"io"
 )
@@ -88,6 +89,9 @@ type Event struct {
 
 	// Value is the value that was changed
 	Value []byte
+
+	// Timestamp is the server time at which the list item changed, as read by primitive.GetTimestamp
+	Timestamp time.Timestamp
 }
 
 // New creates a new list primitive
@@ -248,15 +252,10 @@ func (l *list) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption)
 		return errors.From(err)
 	}
 
-	openCh := make(chan struct{})
+	handshake := primitive.NewHandshake()
 	go func() {
 		defer close(ch)
-		open := false
-		defer func() {
-			if !open {
-				close(openCh)
-			}
-		}()
+		defer handshake.Open()
 		for {
 			response, err := stream.Recv()
 			if err != nil {
@@ -264,6 +263,10 @@ func (l *list) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption)
 					return
 				}
 				err = errors.From(err)
+				// A stream that fails before it is opened fails the watch
+				if handshake.Fail(err) {
+					return
+				}
 				if errors.IsCanceled(err) || errors.IsTimeout(err) {
 					return
 				}
@@ -271,10 +274,11 @@ func (l *list) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption)
 				return
 			}
 
-			if !open {
-				close(openCh)
-				open = true
+			handshake.Open()
+			if response.Event.Type == api.Event_NONE {
+				continue
 			}
+			timestamp := primitive.GetTimestamp(response.Headers)
 			for i := range opts {
 				opts[i].afterWatch(response)
 			}
@@ -286,33 +290,31 @@ func (l *list) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption)
 				switch response.Event.Type {
 				case api.Event_ADD:
 					ch <- Event{
-						Type:  EventAdd,
-						Index: int(response.Event.Item.Index),
-						Value: bytes,
+						Type:      EventAdd,
+						Index:     int(response.Event.Item.Index),
+						Value:     bytes,
+						Timestamp: timestamp,
 					}
 				case api.Event_REMOVE:
 					ch <- Event{
-						Type:  EventRemove,
-						Index: int(response.Event.Item.Index),
-						Value: bytes,
+						Type:      EventRemove,
+						Index:     int(response.Event.Item.Index),
+						Value:     bytes,
+						Timestamp: timestamp,
 					}
 				case api.Event_REPLAY:
 					ch <- Event{
-						Type:  EventReplay,
-						Index: int(response.Event.Item.Index),
-						Value: bytes,
+						Type:      EventReplay,
+						Index:     int(response.Event.Item.Index),
+						Value:     bytes,
+						Timestamp: timestamp,
 					}
 				}
 			}
 		}
 	}()
 
-	select {
-	case <-openCh:
-		return nil
-	case <-ctx.Done():
-		return ctx.Err()
-	}
+	return handshake.Wait(ctx)
 }
 
 func (l *list) Clear(ctx context.Context) error {
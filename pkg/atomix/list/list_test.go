@@ -7,8 +7,8 @@ package list
 import (
 	"context"
 	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-client/pkg/atomix/util/test"
-	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -150,3 +150,111 @@ func TestListOperations(t *testing.T) {
 
 	assert.NoError(t, test.Stop())
 }
+
+func TestListWatchOpen(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestListWatchOpen",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	list, err := New(context.TODO(), "TestListWatchOpen", conn)
+	assert.NoError(t, err)
+
+	ch := make(chan Event)
+	err = list.Watch(context.TODO(), ch)
+	assert.NoError(t, err)
+
+	err = list.Append(context.TODO(), []byte("foo"))
+	assert.NoError(t, err)
+
+	// The open marker completes the handshake and must not be delivered to the consumer
+	event := <-ch
+	assert.Equal(t, EventAdd, event.Type)
+	assert.Equal(t, 0, event.Index)
+	assert.Equal(t, "foo", string(event.Value))
+
+	assert.NoError(t, list.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+func TestListWatchEvents(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestListWatchEvents",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	list, err := New(context.TODO(), "TestListWatchEvents", conn)
+	assert.NoError(t, err)
+
+	events := make(chan Event)
+	err = list.Watch(context.TODO(), events)
+	assert.NoError(t, err)
+
+	// Mirror the list from positional events and compare it to the list contents
+	mirror := make([]string, 0)
+	apply := func(event Event) {
+		switch event.Type {
+		case EventAdd:
+			mirror = append(mirror, "")
+			copy(mirror[event.Index+1:], mirror[event.Index:])
+			mirror[event.Index] = string(event.Value)
+		case EventRemove:
+			assert.Equal(t, mirror[event.Index], string(event.Value))
+			mirror = append(mirror[:event.Index], mirror[event.Index+1:]...)
+		}
+	}
+
+	assert.NoError(t, list.Append(context.TODO(), []byte("foo")))
+	apply(<-events)
+	assert.NoError(t, list.Append(context.TODO(), []byte("bar")))
+	apply(<-events)
+	assert.NoError(t, list.Insert(context.TODO(), 1, []byte("baz")))
+	apply(<-events)
+	assert.Equal(t, []string{"foo", "baz", "bar"}, mirror)
+
+	_, err = list.Remove(context.TODO(), 0)
+	assert.NoError(t, err)
+	event := <-events
+	assert.Equal(t, EventRemove, event.Type)
+	assert.Equal(t, 0, event.Index)
+	assert.Equal(t, "foo", string(event.Value))
+	apply(event)
+
+	// Updates are delivered as a removal followed by an addition at the same index
+	assert.NoError(t, list.Set(context.TODO(), 1, []byte("qux")))
+	event = <-events
+	assert.Equal(t, EventRemove, event.Type)
+	assert.Equal(t, 1, event.Index)
+	assert.Equal(t, "bar", string(event.Value))
+	apply(event)
+	event = <-events
+	assert.Equal(t, EventAdd, event.Type)
+	assert.Equal(t, 1, event.Index)
+	assert.Equal(t, "qux", string(event.Value))
+	apply(event)
+
+	ch := make(chan []byte)
+	assert.NoError(t, list.Items(context.TODO(), ch))
+	items := make([]string, 0)
+	for item := range ch {
+		items = append(items, string(item))
+	}
+	assert.Equal(t, items, mirror)
+
+	assert.NoError(t, list.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package list
+
+import (
+	"context"
+)
+
+// Topic provides lightweight publish/subscribe messaging backed by a List
+// Messages are published by appending them to the list, and subscribers receive messages from the list's
+// event stream in the order in which they were published. Delivery is at-most-once: a subscriber receives
+// only the messages published while its subscription is open, and messages published while a subscriber's
+// stream is disconnected are not redelivered. Published messages are retained in the underlying list until
+// it is cleared.
+type Topic interface {
+	// Publish publishes a message to the topic
+	Publish(ctx context.Context, msg []byte) error
+
+	// Subscribe subscribes to messages published to the topic
+	// This is a non-blocking method. If the method returns without error, messages will be pushed onto the
+	// given channel in the order in which they were published. The channel is closed once the given context
+	// is cancelled.
+	Subscribe(ctx context.Context, ch chan<- []byte) error
+}
+
+// NewTopic returns a new Topic backed by the given List
+// The list should be dedicated to the topic, as values added to the list by other means are delivered
+// to subscribers as messages.
+func NewTopic(list List) Topic {
+	return &topic{
+		list: list,
+	}
+}
+
+// topic is the default implementation of Topic
+type topic struct {
+	list List
+}
+
+func (t *topic) Publish(ctx context.Context, msg []byte) error {
+	return t.list.Append(ctx, msg)
+}
+
+func (t *topic) Subscribe(ctx context.Context, ch chan<- []byte) error {
+	events := make(chan Event)
+	if err := t.list.Watch(ctx, events); err != nil {
+		return err
+	}
+	go func() {
+		defer close(ch)
+		for event := range events {
+			if event.Type == EventAdd {
+				ch <- event.Value
+			}
+		}
+	}()
+	return nil
+}
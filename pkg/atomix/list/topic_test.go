@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package list
+
+import (
+	"context"
+	"fmt"
+	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	"github.com/atomix/atomix-go-client/pkg/atomix/util/test"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTopic(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestTopic",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn1, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	conn2, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	list1, err := New(context.TODO(), "TestTopic", conn1)
+	assert.NoError(t, err)
+
+	list2, err := New(context.TODO(), "TestTopic", conn2)
+	assert.NoError(t, err)
+
+	publisher := NewTopic(list1)
+	subscriber := NewTopic(list2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch1 := make(chan []byte)
+	assert.NoError(t, publisher.Subscribe(ctx, ch1))
+	ch2 := make(chan []byte)
+	assert.NoError(t, subscriber.Subscribe(ctx, ch2))
+
+	const messages = 5
+	go func() {
+		for i := 0; i < messages; i++ {
+			assert.NoError(t, publisher.Publish(context.Background(), []byte(fmt.Sprintf("message-%d", i))))
+		}
+	}()
+
+	// All subscribers receive all messages in order
+	for _, ch := range []chan []byte{ch1, ch2} {
+		for i := 0; i < messages; i++ {
+			select {
+			case msg := <-ch:
+				assert.Equal(t, fmt.Sprintf("message-%d", i), string(msg))
+			case <-time.After(5 * time.Second):
+				t.Fatal("message was not received")
+			}
+		}
+	}
+
+	cancel()
+	_, ok := <-ch1
+	assert.False(t, ok)
+	_, ok = <-ch2
+	assert.False(t, ok)
+
+	assert.NoError(t, list1.Close(context.Background()))
+	assert.NoError(t, list2.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
@@ -7,12 +7,18 @@ package lock
 import (
 	"context"
 	api "github.com/atomix/atomix-api/go/atomix/primitive/lock"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
-	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
 	"google.golang.org/grpc"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+var log = logging.GetLogger("atomix", "client", "lock")
+
 // Type is the lock type
 const Type primitive.Type = "Lock"
 
@@ -29,11 +35,35 @@ type Lock interface {
 	// Lock acquires the lock
 	Lock(ctx context.Context, opts ...LockOption) (Status, error)
 
+	// LockFor attempts to acquire the lock for up to the given maximum wait
+	// The returned bool indicates whether the lock was acquired. If the lock is not acquired within maxWait,
+	// false is returned. Unless the WithTimeout option is set, the wait is also sent to the server as the lock
+	// timeout, so the server withdraws the request from its queue once the wait has elapsed. If the lock is
+	// granted after the wait has elapsed, it is released immediately.
+	LockFor(ctx context.Context, maxWait time.Duration, opts ...LockOption) (bool, error)
+
+	// LockAsync acquires the lock in the background
+	// This is a non-blocking method. The onAcquired callback is invoked once the lock has been acquired, and
+	// onError is invoked if the acquisition fails. The returned function abandons a pending acquisition: neither
+	// callback is invoked once it has been called. The lock service cannot withdraw a queued request, so an
+	// abandoned request keeps its place in the queue, and once the lock is granted to it, it is released
+	// immediately. Waiters queued behind an abandoned request wait for it to be granted and released. To
+	// bound how long an abandoned request can remain queued, set the WithTimeout option. Cancelling the
+	// context does not withdraw the request either, and may leave the lock held by the aborted request.
+	// If onAcquired panics, onError is invoked with an error matching errors.ErrPanic; the lock remains
+	// held and must be released by the caller.
+	LockAsync(ctx context.Context, onAcquired func(Status), onError func(error), opts ...LockOption) (cancel func())
+
 	// Unlock releases the lock
 	Unlock(ctx context.Context, opts ...UnlockOption) error
 
 	// Get gets the lock status
 	Get(ctx context.Context, opts ...GetOption) (Status, error)
+
+	// Token returns the fencing token of the most recent successful acquisition
+	// The token is the revision at which the lock was granted and is guaranteed to increase across successive
+	// acquisitions, even by different holders. Token returns 0 if the lock has not been acquired by this handle.
+	Token() uint64
 }
 
 // Status is the lock status
@@ -77,6 +107,7 @@ type lock struct {
 	*primitive.Client
 	client  api.LockServiceClient
 	options newLockOptions
+	token   uint64
 }
 
 func (l *lock) Lock(ctx context.Context, opts ...LockOption) (Status, error) {
@@ -84,7 +115,9 @@ func (l *lock) Lock(ctx context.Context, opts ...LockOption) (Status, error) {
 		Headers: l.GetHeaders(),
 	}
 	for i := range opts {
-		opts[i].beforeLock(request)
+		if err := opts[i].beforeLock(request); err != nil {
+			return Status{}, err
+		}
 	}
 	response, err := l.client.Lock(ctx, request)
 	if err != nil {
@@ -100,10 +133,127 @@ func (l *lock) Lock(ctx context.Context, opts ...LockOption) (Status, error) {
 	case api.Lock_UNLOCKED:
 		state = StateUnlocked
 	}
-	return Status{
+	status := Status{
 		ObjectMeta: meta.FromProto(response.Lock.ObjectMeta),
 		State:      state,
-	}, nil
+	}
+	if state == StateLocked {
+		atomic.StoreUint64(&l.token, uint64(status.Revision))
+	}
+	return status, nil
+}
+
+func (l *lock) Token() uint64 {
+	return atomic.LoadUint64(&l.token)
+}
+
+// lockResult is the result of a lock request
+type lockResult struct {
+	status Status
+	err    error
+}
+
+func (l *lock) LockFor(ctx context.Context, maxWait time.Duration, opts ...LockOption) (bool, error) {
+	// done is set by whichever of the acquisition or the wait completes first
+	mu := &sync.Mutex{}
+	done := false
+	resultCh := make(chan lockResult, 1)
+	if !hasTimeout(opts) {
+		// The server withdraws the request from its queue once the timeout expires
+		opts = append(opts, WithTimeout(maxWait))
+	}
+	go func() {
+		// The lock request is not aborted once the wait has elapsed: the lock service does not withdraw
+		// aborted requests from its queue, and a request granted after it has been aborted leaves the lock
+		// held. The request is allowed to complete, and the lock is released if it was granted.
+		status, err := l.Lock(ctx, opts...)
+		mu.Lock()
+		expired := done
+		done = true
+		mu.Unlock()
+		if expired {
+			if err == nil {
+				_ = l.Unlock(context.Background())
+			}
+			return
+		}
+		resultCh <- lockResult{status: status, err: err}
+	}()
+
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+	var err error
+	select {
+	case result := <-resultCh:
+		return result.err == nil, result.err
+	case <-timer.C:
+	case <-ctx.Done():
+		err = errors.From(ctx.Err())
+	}
+
+	mu.Lock()
+	completed := done
+	done = true
+	mu.Unlock()
+	if completed {
+		// The acquisition completed concurrently with the wait
+		result := <-resultCh
+		return result.err == nil, result.err
+	}
+	return false, err
+}
+
+// hasTimeout returns whether the given options set the lock timeout
+func hasTimeout(opts []LockOption) bool {
+	for _, opt := range opts {
+		if _, ok := opt.(timeoutOption); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *lock) LockAsync(ctx context.Context, onAcquired func(Status), onError func(error), opts ...LockOption) func() {
+	// done is set by whichever of the acquisition or the cancellation completes first
+	mu := &sync.Mutex{}
+	done := false
+	go func() {
+		// The lock request is not aborted on cancellation: the lock service does not withdraw aborted
+		// requests from its queue, and a request granted after it has been aborted leaves the lock held.
+		// The request is allowed to complete, and the lock is released if it was granted.
+		status, err := l.Lock(ctx, opts...)
+		mu.Lock()
+		cancelled := done
+		done = true
+		mu.Unlock()
+		if cancelled {
+			if err == nil {
+				_ = l.Unlock(context.Background())
+			}
+			return
+		}
+		if err == nil {
+			// A panic in the onAcquired callback is delivered to the onError callback
+			err = l.InvokeCallback(func() error {
+				onAcquired(status)
+				return nil
+			})
+			if err == nil {
+				return
+			}
+		}
+		if err := l.InvokeCallback(func() error {
+			onError(err)
+			return nil
+		}); err != nil {
+			log.Errorf("LockAsync callback failed: %v", err)
+		}
+	}()
+	return func() {
+		mu.Lock()
+		done = true
+		mu.Unlock()
+	}
 }
 
 func (l *lock) Unlock(ctx context.Context, opts ...UnlockOption) error {
@@ -111,7 +261,9 @@ func (l *lock) Unlock(ctx context.Context, opts ...UnlockOption) error {
 		Headers: l.GetHeaders(),
 	}
 	for i := range opts {
-		opts[i].beforeUnlock(request)
+		if err := opts[i].beforeUnlock(request); err != nil {
+			return err
+		}
 	}
 	response, err := l.client.Unlock(ctx, request)
 	if err != nil {
@@ -128,7 +280,9 @@ func (l *lock) Get(ctx context.Context, opts ...GetOption) (Status, error) {
 		Headers: l.GetHeaders(),
 	}
 	for i := range opts {
-		opts[i].beforeGet(request)
+		if err := opts[i].beforeGet(request); err != nil {
+			return Status{}, err
+		}
 	}
 	response, err := l.client.GetLock(ctx, request)
 	if err != nil {
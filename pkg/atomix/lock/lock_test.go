@@ -7,8 +7,8 @@ package lock
 import (
 	"context"
 	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	clienterrors "github.com/atomix/atomix-go-client/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-client/pkg/atomix/util/test"
-	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -79,7 +79,7 @@ func TestLock(t *testing.T) {
 
 	v2, err = l2.Lock(context.Background(), WithTimeout(1*time.Second))
 	assert.Error(t, err)
-	assert.True(t, errors.IsTimeout(err))
+	assert.True(t, clienterrors.IsTimeout(err))
 	assert.Equal(t, StateLocked, v2.State)
 
 	err = l1.Close(context.Background())
@@ -87,3 +87,233 @@ func TestLock(t *testing.T) {
 
 	assert.NoError(t, test.Stop())
 }
+
+func TestLockAsync(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestLockAsync",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn1, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	conn2, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	l1, err := New(context.TODO(), "TestLockAsync", conn1)
+	assert.NoError(t, err)
+	l2, err := New(context.TODO(), "TestLockAsync", conn2)
+	assert.NoError(t, err)
+
+	acquired := make(chan Status, 1)
+	l1.LockAsync(context.Background(), func(status Status) {
+		acquired <- status
+	}, func(err error) {
+		t.Error(err)
+	})
+
+	select {
+	case status := <-acquired:
+		assert.Equal(t, StateLocked, status.State)
+	case <-time.After(5 * time.Second):
+		t.Fatal("lock was not acquired")
+	}
+
+	// Cancel a pending acquisition while the lock is held
+	cancel := l2.LockAsync(context.Background(), func(status Status) {
+		t.Error("cancelled acquisition succeeded")
+	}, func(err error) {
+		t.Error(err)
+	})
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	assert.NoError(t, l1.Unlock(context.Background()))
+
+	// The cancelled acquisition must not retain the lock
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+	status, err := l1.Lock(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, StateLocked, status.State)
+	assert.NoError(t, l1.Unlock(context.Background()))
+
+	// A panic in the onAcquired callback is delivered to the onError callback
+	errCh := make(chan error, 1)
+	l2.LockAsync(context.Background(), func(status Status) {
+		panic("callback failed")
+	}, func(err error) {
+		errCh <- err
+	})
+	select {
+	case err := <-errCh:
+		assert.True(t, clienterrors.IsPanic(err))
+	case <-time.After(5 * time.Second):
+		t.Fatal("panic was not delivered")
+	}
+
+	// The lock remains usable once released
+	assert.NoError(t, l2.Unlock(context.Background()))
+	status, err = l1.Lock(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, StateLocked, status.State)
+
+	assert.NoError(t, l1.Close(context.Background()))
+	assert.NoError(t, l2.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+func TestLockFor(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestLockFor",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn1, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	conn2, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	l1, err := New(context.TODO(), "TestLockFor", conn1)
+	assert.NoError(t, err)
+	l2, err := New(context.TODO(), "TestLockFor", conn2)
+	assert.NoError(t, err)
+
+	// The lock is acquired if it is free
+	acquired, err := l1.LockFor(context.Background(), time.Second)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	// The acquisition gives up once the wait has elapsed
+	start := time.Now()
+	acquired, err = l2.LockFor(context.Background(), 100*time.Millisecond)
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+	assert.True(t, time.Since(start) < 5*time.Second)
+
+	// The expired acquisition must not retain the lock once it is released
+	assert.NoError(t, l1.Unlock(context.Background()))
+
+	// The server withdrew the expired request, so the lock was not granted to it
+	status, err := l2.Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, StateUnlocked, status.State)
+
+	acquired, err = l1.LockFor(context.Background(), 5*time.Second)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	// The lock is acquired if it is released within the wait
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		assert.NoError(t, l1.Unlock(context.Background()))
+	}()
+	acquired, err = l2.LockFor(context.Background(), 5*time.Second)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	status, err = l1.Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, StateLocked, status.State)
+
+	assert.NoError(t, l1.Close(context.Background()))
+	assert.NoError(t, l2.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+func TestLockToken(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestLockToken",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn1, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+	conn2, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	l1, err := New(context.TODO(), "TestLockToken", conn1)
+	assert.NoError(t, err)
+	l2, err := New(context.TODO(), "TestLockToken", conn2)
+	assert.NoError(t, err)
+
+	assert.Equal(t, uint64(0), l1.Token())
+
+	status, err := l1.Lock(context.Background())
+	assert.NoError(t, err)
+	token1 := l1.Token()
+	assert.NotEqual(t, uint64(0), token1)
+	assert.Equal(t, uint64(status.Revision), token1)
+	assert.NoError(t, l1.Unlock(context.Background()))
+
+	_, err = l2.Lock(context.Background())
+	assert.NoError(t, err)
+	token2 := l2.Token()
+	assert.Greater(t, token2, token1)
+	assert.NoError(t, l2.Unlock(context.Background()))
+
+	_, err = l1.Lock(context.Background())
+	assert.NoError(t, err)
+	token3 := l1.Token()
+	assert.Greater(t, token3, token2)
+
+	// A failed acquisition does not change the token
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	_, err = l2.Lock(ctx)
+	cancel()
+	assert.Error(t, err)
+	assert.Equal(t, token2, l2.Token())
+	assert.NoError(t, l1.Unlock(context.Background()))
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestLockInvalidOption(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestLockInvalidOption",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	l, err := New(context.TODO(), "TestLockInvalidOption", conn)
+	assert.NoError(t, err)
+
+	_, err = l.Lock(context.Background(), WithTimeout(-time.Second))
+	assert.True(t, clienterrors.IsInvalidOption(err))
+
+	acquired, err := l.LockFor(context.Background(), time.Second, WithTimeout(-time.Second))
+	assert.False(t, acquired)
+	assert.True(t, clienterrors.IsInvalidOption(err))
+
+	err = l.Unlock(context.Background(), IfMatch(nil))
+	assert.True(t, clienterrors.IsInvalidOption(err))
+
+	_, err = l.Get(context.Background(), IfMatch(nil))
+	assert.True(t, clienterrors.IsInvalidOption(err))
+
+	// The rejected Lock call was never sent, so the lock remains unlocked
+	status, err := l.Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, StateUnlocked, status.State)
+
+	assert.NoError(t, test.Stop())
+}
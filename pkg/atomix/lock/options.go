@@ -6,6 +6,7 @@ package lock
 
 import (
 	api "github.com/atomix/atomix-api/go/atomix/primitive/lock"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
 	"time"
@@ -23,11 +24,13 @@ type newLockOptions struct{}
 // LockOption is an option for Lock calls
 //nolint:golint
 type LockOption interface {
-	beforeLock(request *api.LockRequest)
+	beforeLock(request *api.LockRequest) error
 	afterLock(response *api.LockResponse)
 }
 
 // WithTimeout sets the lock timeout
+// The timeout must not be negative; a negative timeout fails the Lock call with an error matching
+// errors.ErrInvalidOption.
 func WithTimeout(timeout time.Duration) LockOption {
 	return timeoutOption{timeout: timeout}
 }
@@ -36,8 +39,12 @@ type timeoutOption struct {
 	timeout time.Duration
 }
 
-func (o timeoutOption) beforeLock(request *api.LockRequest) {
+func (o timeoutOption) beforeLock(request *api.LockRequest) error {
+	if o.timeout < 0 {
+		return errors.NewInvalidOption("lock timeout %s is negative", o.timeout)
+	}
 	request.Timeout = &o.timeout
+	return nil
 }
 
 func (o timeoutOption) afterLock(response *api.LockResponse) {
@@ -46,17 +53,18 @@ func (o timeoutOption) afterLock(response *api.LockResponse) {
 
 // UnlockOption is an option for Unlock calls
 type UnlockOption interface {
-	beforeUnlock(request *api.UnlockRequest)
+	beforeUnlock(request *api.UnlockRequest) error
 	afterUnlock(response *api.UnlockResponse)
 }
 
 // GetOption is an option for IsLocked calls
 type GetOption interface {
-	beforeGet(request *api.GetLockRequest)
+	beforeGet(request *api.GetLockRequest) error
 	afterGet(response *api.GetLockResponse)
 }
 
 // IfMatch sets the lock version to check
+// The object must not be nil; a nil object fails the call with an error matching errors.ErrInvalidOption.
 func IfMatch(object meta.Object) MatchOption {
 	return MatchOption{object: object}
 }
@@ -66,16 +74,24 @@ type MatchOption struct {
 	object meta.Object
 }
 
-func (o MatchOption) beforeUnlock(request *api.UnlockRequest) {
+func (o MatchOption) beforeUnlock(request *api.UnlockRequest) error {
+	if o.object == nil {
+		return errors.NewInvalidOption("lock match object is nil")
+	}
 	request.Lock.ObjectMeta = o.object.Meta().Proto()
+	return nil
 }
 
 func (o MatchOption) afterUnlock(response *api.UnlockResponse) {
 
 }
 
-func (o MatchOption) beforeGet(request *api.GetLockRequest) {
+func (o MatchOption) beforeGet(request *api.GetLockRequest) error {
+	if o.object == nil {
+		return errors.NewInvalidOption("lock match object is nil")
+	}
 	request.Lock.ObjectMeta = o.object.Meta().Proto()
+	return nil
 }
 
 func (o MatchOption) afterGet(response *api.GetLockResponse) {
@@ -7,6 +7,7 @@ package lock
 import (
 	api "github.com/atomix/atomix-api/go/atomix/primitive/lock"
 	metaapi "github.com/atomix/atomix-api/go/atomix/primitive/meta"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -27,3 +28,18 @@ func TestOptions(t *testing.T) {
 	IfMatch(meta.ObjectMeta{Revision: 2}).beforeGet(getLockRequest)
 	assert.Equal(t, metaapi.RevisionNum(2), getLockRequest.Lock.ObjectMeta.Revision.Num)
 }
+
+func TestInvalidOptions(t *testing.T) {
+	lockRequest := &api.LockRequest{}
+	err := WithTimeout(-time.Second).beforeLock(lockRequest)
+	assert.True(t, errors.IsInvalidOption(err))
+	assert.True(t, errors.IsInvalid(err))
+	assert.Nil(t, lockRequest.Timeout)
+	assert.NoError(t, WithTimeout(0).beforeLock(lockRequest))
+
+	err = IfMatch(nil).beforeUnlock(&api.UnlockRequest{})
+	assert.True(t, errors.IsInvalidOption(err))
+
+	err = IfMatch(nil).beforeGet(&api.GetLockRequest{})
+	assert.True(t, errors.IsInvalidOption(err))
+}
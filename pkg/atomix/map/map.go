@@ -5,15 +5,22 @@
 package _map //nolint:golint
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	api "github.com/atomix/atomix-api/go/atomix/primitive/map"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
-	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"github.com/atomix/atomix-go-client/pkg/atomix/util"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/time"
 	"google.golang.org/grpc"
 	"io"
+	"sort"
+	"strings"
+	"sync"
 )
 
 // Type is the map type
@@ -34,9 +41,48 @@ type Map interface {
 	// Put sets a key/value pair in the map
 	Put(ctx context.Context, key string, value []byte, opts ...PutOption) (*Entry, error)
 
+	// PutIfValue sets the value of the given key only if its current value equals the expected value
+	// The returned bool indicates whether the value was written. If the key is not present in the map,
+	// the value is not written.
+	PutIfValue(ctx context.Context, key string, expected, value []byte, opts ...PutOption) (bool, error)
+
+	// MergeJSON merges the given patch into the JSON object value of the given key
+	// The merged value is written only if the value has not changed since it was read, and the merge is retried
+	// on conflicts. If the key is not present in the map, the patch is written as the value. If the current value
+	// is not a JSON object, an Invalid error is returned.
+	MergeJSON(ctx context.Context, key string, patch map[string]interface{}, opts ...MergeOption) error
+
+	// ComputeIfAbsent writes the value computed by the given function only if the given key is not present
+	// If the key is present, the function is not called and the current entry is returned. If the function
+	// returns a nil value, nothing is written and a nil entry is returned. The write is retried if the key
+	// is added concurrently. If the function panics, an error matching errors.ErrPanic is returned.
+	ComputeIfAbsent(ctx context.Context, key string, fn func() ([]byte, error)) (*Entry, error)
+
+	// ComputeIfPresent updates the value of the given key with the value computed by the given function
+	// only if the key is present
+	// The function is called with the current value. If the function returns a nil value, the key is
+	// removed. The write is guarded by the revision that was read and is retried on conflicts, so the
+	// function may be called more than once. If the key is not present or is removed, a nil entry is returned.
+	// If the function panics, an error matching errors.ErrPanic is returned.
+	ComputeIfPresent(ctx context.Context, key string, fn func(old []byte) ([]byte, error)) (*Entry, error)
+
 	// Get gets the value of the given key
+	// If the key is not present in the map, a nil entry and an error matching errors.ErrNotFound are returned.
+	// Other failures, e.g. transport errors, never match errors.ErrNotFound.
 	Get(ctx context.Context, key string, opts ...GetOption) (*Entry, error)
 
+	// GetVersion gets the current version of the given key
+	// The version is the revision of the entry, which changes each time the entry is updated. Pass the version
+	// to IfVersion to make a subsequent Put or Remove conditional on the entry not having changed. The API has
+	// no way to omit the value from a read, so the entry is read in full, but only its version is returned.
+	// If the key is not present in the map, an error matching errors.ErrNotFound is returned.
+	GetVersion(ctx context.Context, key string) (Version, error)
+
+	// GetAll gets the values of the given keys
+	// Keys that are not present in the map are absent from the returned map. If any key cannot be read,
+	// a KeyErrors error is returned mapping each failed key to its error.
+	GetAll(ctx context.Context, keys []string, opts ...GetOption) (map[string][]byte, error)
+
 	// Remove removes a key from the map
 	Remove(ctx context.Context, key string, opts ...RemoveOption) (*Entry, error)
 
@@ -48,13 +94,42 @@ type Map interface {
 
 	// Entries lists the entries in the map
 	// This is a non-blocking method. If the method returns without error, key/value paids will be pushed on to the
-	// given channel and the channel will be closed once all entries have been read from the map.
-	Entries(ctx context.Context, ch chan<- Entry) error
+	// given channel and the channel will be closed once all entries have been read from the map. If the context is
+	// cancelled, the stream is closed and the channel is closed without waiting for the remaining entries to be read.
+	Entries(ctx context.Context, ch chan<- Entry, opts ...EntriesOption) error
+
+	// Diff computes the changes required to bring the map to the desired state
+	// The map's entries are streamed and compared against the desired state: toPut contains the keys that
+	// must be added or updated mapped to their desired values, and toRemove contains the keys that must be
+	// removed mapped to their current values. Only the desired state and the changes are held in memory.
+	Diff(ctx context.Context, desired map[string][]byte) (toPut, toRemove map[string][]byte, err error)
+
+	// ReconcileTo applies the changes required to bring the map to the desired state
+	// Each change is guarded by the revision of the entry that was read, so keys modified concurrently
+	// are not overwritten. The returned ReconcileResult contains the changes that were applied; if any
+	// change cannot be applied, a KeyErrors error is returned mapping each failed key to its error.
+	ReconcileTo(ctx context.Context, desired map[string][]byte, opts ...ReconcileOption) (ReconcileResult, error)
 
 	// Watch watches the map for changes
 	// This is a non-blocking method. If the method returns without error, map events will be pushed onto
-	// the given channel in the order in which they occur.
+	// the given channel in the order in which they occur. Each watch opens its own stream to the server unless
+	// the WithSharedStream option is set.
 	Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption) error
+
+	// GetAndWatch gets a snapshot of the map's entries and watches the map for changes after the snapshot
+	// This is a non-blocking method. If the method returns without error, events for changes made after the
+	// snapshot will be pushed onto the given channel in the order in which they occur. Events for changes
+	// already reflected in the snapshot are not delivered. If an error is returned, the channel is closed.
+	GetAndWatch(ctx context.Context, ch chan<- Event) (Snapshot, error)
+}
+
+// Snapshot is a snapshot of the entries in a map
+type Snapshot struct {
+	// Entries is the entries in the map, keyed by key
+	Entries map[string]Entry
+
+	// Revision is the highest revision of any entry in the snapshot
+	Revision meta.Revision
 }
 
 // Version is an entry version
@@ -71,6 +146,37 @@ func newEntry(entry *api.Entry) *Entry {
 	}
 }
 
+// getAllParallelism is the maximum number of concurrent reads issued by GetAll
+const getAllParallelism = 10
+
+// defaultReconcileParallelism is the default maximum number of concurrent changes applied by ReconcileTo
+const defaultReconcileParallelism = 10
+
+// ReconcileResult is the set of changes applied to reconcile a map with a desired state
+type ReconcileResult struct {
+	// Put is the set of keys that were added or updated mapped to their new values
+	Put map[string][]byte
+
+	// Removed is the set of keys that were removed mapped to their prior values
+	Removed map[string][]byte
+}
+
+// KeyErrors is an error returned by operations on multiple keys, mapping each failed key to its error
+type KeyErrors map[string]error
+
+func (e KeyErrors) Error() string {
+	keys := make([]string, 0, len(e))
+	for key := range e {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	messages := make([]string, len(keys))
+	for i, key := range keys {
+		messages[i] = fmt.Sprintf("%s: %v", key, e[key])
+	}
+	return strings.Join(messages, "; ")
+}
+
 // Entry is a versioned key/value pair
 type Entry struct {
 	meta.ObjectMeta
@@ -110,6 +216,14 @@ type Event struct {
 
 	// Entry is the event entry
 	Entry Entry
+
+	// Timestamp is the server time at which the entry changed, as read by primitive.GetTimestamp
+	Timestamp time.Timestamp
+
+	// PrevValue is the value of the entry before an update
+	// PrevValue is set only for update events delivered to watches opened with the WithPrevValues option,
+	// and is nil if the watch has not seen the entry's previous value.
+	PrevValue []byte
 }
 
 // New creates a new partitioned Map
@@ -124,6 +238,10 @@ func New(ctx context.Context, name string, conn *grpc.ClientConn, opts ...primit
 		Client:  primitive.NewClient(Type, name, conn, opts...),
 		client:  api.NewMapServiceClient(conn),
 		options: options,
+		mux: watchMux{
+			historySize: options.watchHistory,
+			queueSize:   options.watchQueueSize,
+		},
 	}
 	if err := m.Create(ctx); err != nil {
 		return nil, err
@@ -135,6 +253,7 @@ type _map struct {
 	*primitive.Client
 	client  api.MapServiceClient
 	options newMapOptions
+	mux     watchMux
 }
 
 func (m *_map) Put(ctx context.Context, key string, value []byte, opts ...PutOption) (*Entry, error) {
@@ -152,6 +271,9 @@ func (m *_map) Put(ctx context.Context, key string, value []byte, opts ...PutOpt
 	for i := range opts {
 		opts[i].beforePut(request)
 	}
+	if request.Entry.Value.TTL != nil && *request.Entry.Value.TTL <= 0 {
+		return nil, errors.NewInvalid("TTL must be positive")
+	}
 	response, err := m.client.Put(ctx, request)
 	if err != nil {
 		return nil, errors.From(err)
@@ -159,9 +281,160 @@ func (m *_map) Put(ctx context.Context, key string, value []byte, opts ...PutOpt
 	for i := range opts {
 		opts[i].afterPut(response)
 	}
+	// An entry written with a TTL is returned with its remaining TTL unless the TTL was not applied
+	if request.Entry.Value.TTL != nil && (response.Entry.Value == nil || response.Entry.Value.TTL == nil) {
+		return nil, errors.NewNotSupported("TTL was not applied to key %s", key)
+	}
 	return newEntry(&response.Entry), nil
 }
 
+func (m *_map) PutIfValue(ctx context.Context, key string, expected, value []byte, opts ...PutOption) (bool, error) {
+	for {
+		entry, err := m.Get(ctx, key)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		if !bytes.Equal(entry.Value, expected) {
+			return false, nil
+		}
+
+		// Guard the write with the revision that was read to ensure the value has not changed in the interim
+		_, err = m.Put(ctx, key, value, append([]PutOption{IfMatch(entry)}, opts...)...)
+		if err == nil {
+			return true, nil
+		}
+		if !errors.IsConflict(err) {
+			return false, err
+		}
+	}
+}
+
+func (m *_map) MergeJSON(ctx context.Context, key string, patch map[string]interface{}, opts ...MergeOption) error {
+	options := mergeOptions{}
+	for _, opt := range opts {
+		opt.applyMerge(&options)
+	}
+
+	for {
+		var putOpts []PutOption
+		object := make(map[string]interface{})
+		entry, err := m.Get(ctx, key)
+		if err == nil {
+			if err := json.Unmarshal(entry.Value, &object); err != nil || object == nil {
+				return errors.NewInvalid("value of key '%s' is not a JSON object", key)
+			}
+			putOpts = append(putOpts, IfMatch(entry))
+		} else if errors.IsNotFound(err) {
+			putOpts = append(putOpts, IfNotSet())
+		} else {
+			return err
+		}
+
+		mergeJSON(object, patch, options.deep)
+		value, err := json.Marshal(object)
+		if err != nil {
+			return errors.NewInvalid("failed to encode merged value: %v", err)
+		}
+
+		_, err = m.Put(ctx, key, value, putOpts...)
+		if err == nil {
+			return nil
+		}
+		if !errors.IsConflict(err) && !errors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+}
+
+func (m *_map) ComputeIfAbsent(ctx context.Context, key string, fn func() ([]byte, error)) (*Entry, error) {
+	for {
+		entry, err := m.Get(ctx, key)
+		if err == nil {
+			return entry, nil
+		}
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+
+		var value []byte
+		err = m.InvokeCallback(func() (err error) {
+			value, err = fn()
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			return nil, nil
+		}
+
+		entry, err = m.Put(ctx, key, value, IfNotSet())
+		if err == nil {
+			return entry, nil
+		}
+		if !errors.IsAlreadyExists(err) && !errors.IsConflict(err) {
+			return nil, err
+		}
+	}
+}
+
+func (m *_map) ComputeIfPresent(ctx context.Context, key string, fn func(old []byte) ([]byte, error)) (*Entry, error) {
+	for {
+		entry, err := m.Get(ctx, key)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		var value []byte
+		err = m.InvokeCallback(func() (err error) {
+			value, err = fn(entry.Value)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// Guard the write with the revision that was read to ensure the value has not changed in the interim
+		if value == nil {
+			_, err = m.Remove(ctx, key, IfMatch(entry))
+			if err == nil {
+				return nil, nil
+			}
+		} else {
+			entry, err = m.Put(ctx, key, value, IfMatch(entry))
+			if err == nil {
+				return entry, nil
+			}
+		}
+		if !errors.IsConflict(err) && !errors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+}
+
+// mergeJSON merges the given patch into the given object
+// If deep is true, nested objects present in both the object and the patch are merged recursively.
+// Otherwise, the values in the patch replace the values in the object.
+func mergeJSON(object, patch map[string]interface{}, deep bool) {
+	for key, value := range patch {
+		if deep {
+			patchObject, patchOK := value.(map[string]interface{})
+			valueObject, valueOK := object[key].(map[string]interface{})
+			if patchOK && valueOK {
+				mergeJSON(valueObject, patchObject, deep)
+				continue
+			}
+		}
+		object[key] = value
+	}
+}
+
 func (m *_map) Get(ctx context.Context, key string, opts ...GetOption) (*Entry, error) {
 	request := &api.GetRequest{
 		Headers: m.GetHeaders(),
@@ -170,7 +443,7 @@ func (m *_map) Get(ctx context.Context, key string, opts ...GetOption) (*Entry,
 	for i := range opts {
 		opts[i].beforeGet(request)
 	}
-	response, err := m.client.Get(ctx, request)
+	response, err := m.get(ctx, request, opts...)
 	if err != nil {
 		return nil, errors.From(err)
 	}
@@ -180,6 +453,62 @@ func (m *_map) Get(ctx context.Context, key string, opts ...GetOption) (*Entry,
 	return newEntry(&response.Entry), nil
 }
 
+func (m *_map) GetVersion(ctx context.Context, key string) (Version, error) {
+	entry, err := m.Get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	return Version(entry.Revision), nil
+}
+
+func (m *_map) GetAll(ctx context.Context, keys []string, opts ...GetOption) (map[string][]byte, error) {
+	values := make(map[string][]byte)
+	errs := make(KeyErrors)
+	mu := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	sem := make(chan struct{}, getAllParallelism)
+	for _, key := range keys {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(key string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			entry, err := m.Get(ctx, key, opts...)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if !errors.IsNotFound(err) {
+					errs[key] = err
+				}
+				return
+			}
+			values[key] = entry.Value
+		}(key)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return values, errs
+	}
+	return values, nil
+}
+
+func (m *_map) get(ctx context.Context, request *api.GetRequest, opts ...GetOption) (*api.GetResponse, error) {
+	for i := range opts {
+		if hedging, ok := opts[i].(hedgingOption); ok {
+			response, err := util.Hedge(ctx, hedging.delay, func(ctx context.Context) (interface{}, error) {
+				return m.client.Get(ctx, request)
+			})
+			if err != nil {
+				return nil, err
+			}
+			return response.(*api.GetResponse), nil
+		}
+	}
+	return m.client.Get(ctx, request)
+}
+
 func (m *_map) Remove(ctx context.Context, key string, opts ...RemoveOption) (*Entry, error) {
 	request := &api.RemoveRequest{
 		Headers: m.GetHeaders(),
@@ -222,10 +551,17 @@ func (m *_map) Clear(ctx context.Context) error {
 	return nil
 }
 
-func (m *_map) Entries(ctx context.Context, ch chan<- Entry) error {
+func (m *_map) Entries(ctx context.Context, ch chan<- Entry, opts ...EntriesOption) error {
 	request := &api.EntriesRequest{
 		Headers: m.GetHeaders(),
 	}
+	sorted := false
+	for i := range opts {
+		opts[i].beforeEntries(request)
+		if _, ok := opts[i].(sortedKeysOption); ok {
+			sorted = true
+		}
+	}
 	stream, err := m.client.Entries(ctx, request)
 	if err != nil {
 		return errors.From(err)
@@ -233,10 +569,24 @@ func (m *_map) Entries(ctx context.Context, ch chan<- Entry) error {
 
 	go func() {
 		defer close(ch)
+		// Sorted entries are buffered until the stream is complete
+		var entries []Entry
 		for {
 			response, err := stream.Recv()
 			if err != nil {
 				if err == io.EOF {
+					if sorted {
+						sort.Slice(entries, func(i, j int) bool {
+							return entries[i].Key < entries[j].Key
+						})
+						for _, entry := range entries {
+							select {
+							case ch <- entry:
+							case <-ctx.Done():
+								return
+							}
+						}
+					}
 					return
 				}
 				err = errors.From(err)
@@ -247,22 +597,344 @@ func (m *_map) Entries(ctx context.Context, ch chan<- Entry) error {
 				return
 			}
 
-			ch <- Entry{
+			for i := range opts {
+				opts[i].afterEntries(response)
+			}
+			entry := Entry{
 				ObjectMeta: meta.FromProto(response.Entry.Key.ObjectMeta),
 				Key:        response.Entry.Key.Key,
 				Value:      response.Entry.Value.Value,
 			}
+			if sorted {
+				entries = append(entries, entry)
+			} else {
+				// Stop delivering entries once the context is done, even if the consumer stops reading
+				select {
+				case ch <- entry:
+				case <-ctx.Done():
+					return
+				}
+			}
 		}
 	}()
 	return nil
 }
 
+func (m *_map) Diff(ctx context.Context, desired map[string][]byte) (map[string][]byte, map[string][]byte, error) {
+	changes, err := m.diff(ctx, desired)
+	if err != nil {
+		return nil, nil, err
+	}
+	toPut := make(map[string][]byte)
+	toRemove := make(map[string][]byte)
+	for _, change := range changes {
+		if change.remove {
+			toRemove[change.key] = change.current.Value
+		} else {
+			toPut[change.key] = change.value
+		}
+	}
+	return toPut, toRemove, nil
+}
+
+// change is a change required to bring a key to its desired state
+type change struct {
+	key     string
+	value   []byte
+	current *Entry
+	remove  bool
+}
+
+// diff streams the map's entries and computes the changes required to bring the map to the desired state
+func (m *_map) diff(ctx context.Context, desired map[string][]byte) ([]change, error) {
+	request := &api.EntriesRequest{
+		Headers: m.GetHeaders(),
+	}
+	stream, err := m.client.Entries(ctx, request)
+	if err != nil {
+		return nil, errors.From(err)
+	}
+
+	var changes []change
+
+	// missing tracks the desired keys that have not been found in the map
+	missing := make(map[string]bool, len(desired))
+	for key := range desired {
+		missing[key] = true
+	}
+
+	for {
+		response, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.From(err)
+		}
+
+		entry := &Entry{
+			ObjectMeta: meta.FromProto(response.Entry.Key.ObjectMeta),
+			Key:        response.Entry.Key.Key,
+		}
+		if response.Entry.Value != nil {
+			entry.Value = response.Entry.Value.Value
+		}
+		value, ok := desired[entry.Key]
+		if !ok {
+			changes = append(changes, change{key: entry.Key, current: entry, remove: true})
+			continue
+		}
+		delete(missing, entry.Key)
+		if !bytes.Equal(entry.Value, value) {
+			changes = append(changes, change{key: entry.Key, value: value, current: entry})
+		}
+	}
+
+	for key := range missing {
+		changes = append(changes, change{key: key, value: desired[key]})
+	}
+	return changes, nil
+}
+
+func (m *_map) ReconcileTo(ctx context.Context, desired map[string][]byte, opts ...ReconcileOption) (ReconcileResult, error) {
+	options := reconcileOptions{
+		parallelism: defaultReconcileParallelism,
+	}
+	for _, opt := range opts {
+		opt.applyReconcile(&options)
+	}
+	if options.parallelism <= 0 {
+		return ReconcileResult{}, errors.NewInvalid("parallelism must be positive")
+	}
+
+	changes, err := m.diff(ctx, desired)
+	if err != nil {
+		return ReconcileResult{}, err
+	}
+
+	result := ReconcileResult{
+		Put:     make(map[string][]byte),
+		Removed: make(map[string][]byte),
+	}
+	if options.dryRun {
+		for _, change := range changes {
+			if change.remove {
+				result.Removed[change.key] = change.current.Value
+			} else {
+				result.Put[change.key] = change.value
+			}
+		}
+		return result, nil
+	}
+
+	errs := make(KeyErrors)
+	mu := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	sem := make(chan struct{}, options.parallelism)
+	for _, c := range changes {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(change change) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			// Guard each change with the state that was read to avoid overwriting concurrent modifications
+			var err error
+			if change.remove {
+				_, err = m.Remove(ctx, change.key, IfMatch(change.current))
+			} else if change.current != nil {
+				_, err = m.Put(ctx, change.key, change.value, IfMatch(change.current))
+			} else {
+				_, err = m.Put(ctx, change.key, change.value, IfNotSet())
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				// A key that has already been removed has reached its desired state
+				if !change.remove || !errors.IsNotFound(err) {
+					errs[change.key] = err
+				}
+				return
+			}
+			if change.remove {
+				result.Removed[change.key] = change.current.Value
+			} else {
+				result.Put[change.key] = change.value
+			}
+		}(c)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return result, errs
+	}
+	return result, nil
+}
+
 func (m *_map) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption) error {
+	// Shared watches without stream options share a single upstream stream
+	shared, history := false, false
+	streamOpts := make([]WatchOption, 0, len(opts))
+	for _, opt := range opts {
+		switch opt.(type) {
+		case sharedStreamOption:
+			shared = true
+		case historyOption:
+			shared, history = true, true
+		default:
+			streamOpts = append(streamOpts, opt)
+		}
+	}
+	if shared && len(streamOpts) == 0 {
+		return m.mux.subscribe(ctx, ch, m.watch, history)
+	}
+	return m.watch(ctx, ch, streamOpts...)
+}
+
+func (m *_map) GetAndWatch(ctx context.Context, ch chan<- Event) (Snapshot, error) {
+	// The watch is opened before the entries are read so no change made after the snapshot is missed.
+	// Events received before the snapshot is complete are buffered and filtered against the snapshot.
+	watchCtx, cancel := context.WithCancel(ctx)
+	events := make(chan Event)
+	if err := m.watch(watchCtx, events); err != nil {
+		cancel()
+		close(ch)
+		return Snapshot{}, err
+	}
+
+	snapshotCh := make(chan Snapshot, 1)
+	go func() {
+		defer close(ch)
+		defer func() {
+			cancel()
+			for range events {
+			}
+		}()
+
+		var pending []Event
+		var filter *snapshotFilter
+		for filter == nil {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				pending = append(pending, event)
+			case snapshot, ok := <-snapshotCh:
+				if !ok {
+					return
+				}
+				filter = newSnapshotFilter(snapshot)
+			}
+		}
+		for _, event := range pending {
+			if filter.accept(event) {
+				ch <- event
+			}
+		}
+		for event := range events {
+			if filter.accept(event) {
+				ch <- event
+			}
+		}
+	}()
+
+	snapshot, err := m.snapshot(ctx)
+	if err != nil {
+		close(snapshotCh)
+		return Snapshot{}, err
+	}
+	snapshotCh <- snapshot
+	return snapshot, nil
+}
+
+// snapshot reads a snapshot of the map's entries
+func (m *_map) snapshot(ctx context.Context) (Snapshot, error) {
+	request := &api.EntriesRequest{
+		Headers: m.GetHeaders(),
+	}
+	stream, err := m.client.Entries(ctx, request)
+	if err != nil {
+		return Snapshot{}, errors.From(err)
+	}
+
+	snapshot := Snapshot{
+		Entries: make(map[string]Entry),
+	}
+	for {
+		response, err := stream.Recv()
+		if err == io.EOF {
+			return snapshot, nil
+		}
+		if err != nil {
+			return Snapshot{}, errors.From(err)
+		}
+		entry := newEntry(&response.Entry)
+		snapshot.Entries[entry.Key] = *entry
+		if entry.Revision > snapshot.Revision {
+			snapshot.Revision = entry.Revision
+		}
+	}
+}
+
+// newSnapshotFilter returns a filter for the events following the given snapshot
+func newSnapshotFilter(snapshot Snapshot) *snapshotFilter {
+	revisions := make(map[string]meta.Revision)
+	for key, entry := range snapshot.Entries {
+		revisions[key] = entry.Revision
+	}
+	return &snapshotFilter{
+		revision:  snapshot.Revision,
+		revisions: revisions,
+	}
+}
+
+// snapshotFilter filters out events for changes already reflected in a snapshot
+// Revisions are assigned in the order in which changes are made, so a change is reflected in the snapshot
+// if its revision is not greater than the revision known for its key. Keys that are not present in the
+// snapshot are known at the highest revision in the snapshot. Delivered events update the known revisions.
+type snapshotFilter struct {
+	revision  meta.Revision
+	revisions map[string]meta.Revision
+}
+
+// accept returns whether the given event should be delivered
+func (f *snapshotFilter) accept(event Event) bool {
+	key, revision := event.Entry.Key, event.Entry.Revision
+	known, ok := f.revisions[key]
+	switch event.Type {
+	case EventRemove:
+		// Removals carry the revision of the removed entry, which must be the known entry
+		if !ok || revision != known {
+			return false
+		}
+		delete(f.revisions, key)
+		return true
+	default:
+		if !ok {
+			known = f.revision
+		}
+		if revision <= known {
+			return false
+		}
+		f.revisions[key] = revision
+		return true
+	}
+}
+
+// watch opens a dedicated watch stream
+func (m *_map) watch(ctx context.Context, ch chan<- Event, opts ...WatchOption) error {
 	request := &api.EventsRequest{
 		Headers: m.GetHeaders(),
 	}
+	var prevValues map[string][]byte
 	for i := range opts {
 		opts[i].beforeWatch(request)
+		if _, ok := opts[i].(prevValuesOption); ok {
+			prevValues = make(map[string][]byte)
+		}
 	}
 
 	stream, err := m.client.Events(ctx, request)
@@ -270,15 +942,10 @@ func (m *_map) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption)
 		return errors.From(err)
 	}
 
-	openCh := make(chan struct{})
+	handshake := primitive.NewHandshake()
 	go func() {
 		defer close(ch)
-		open := false
-		defer func() {
-			if !open {
-				close(openCh)
-			}
-		}()
+		defer handshake.Open()
 		for {
 			response, err := stream.Recv()
 			if err != nil {
@@ -286,6 +953,10 @@ func (m *_map) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption)
 					return
 				}
 				err = errors.From(err)
+				// A stream that fails before it is opened fails the watch
+				if handshake.Fail(err) {
+					return
+				}
 				if errors.IsCanceled(err) || errors.IsTimeout(err) {
 					return
 				}
@@ -293,44 +964,56 @@ func (m *_map) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption)
 				return
 			}
 
-			if !open {
-				close(openCh)
-				open = true
+			handshake.Open()
+			if response.Event.Type == api.Event_NONE {
+				continue
 			}
+			timestamp := primitive.GetTimestamp(response.Headers)
 
 			for i := range opts {
 				opts[i].afterWatch(response)
 			}
 
+			var prevValue []byte
+			if prevValues != nil {
+				key := response.Event.Entry.Key.Key
+				prevValue = prevValues[key]
+				if response.Event.Type == api.Event_REMOVE {
+					delete(prevValues, key)
+				} else if response.Event.Entry.Value != nil {
+					prevValues[key] = response.Event.Entry.Value.Value
+				}
+			}
+
 			switch response.Event.Type {
 			case api.Event_INSERT:
 				ch <- Event{
-					Type:  EventInsert,
-					Entry: *newEntry(&response.Event.Entry),
+					Type:      EventInsert,
+					Entry:     *newEntry(&response.Event.Entry),
+					Timestamp: timestamp,
 				}
 			case api.Event_UPDATE:
 				ch <- Event{
-					Type:  EventUpdate,
-					Entry: *newEntry(&response.Event.Entry),
+					Type:      EventUpdate,
+					Entry:     *newEntry(&response.Event.Entry),
+					Timestamp: timestamp,
+					PrevValue: prevValue,
 				}
 			case api.Event_REMOVE:
 				ch <- Event{
-					Type:  EventRemove,
-					Entry: *newEntry(&response.Event.Entry),
+					Type:      EventRemove,
+					Entry:     *newEntry(&response.Event.Entry),
+					Timestamp: timestamp,
 				}
 			case api.Event_REPLAY:
 				ch <- Event{
-					Type:  EventReplay,
-					Entry: *newEntry(&response.Event.Entry),
+					Type:      EventReplay,
+					Entry:     *newEntry(&response.Event.Entry),
+					Timestamp: timestamp,
 				}
 			}
 		}
 	}()
 
-	select {
-	case <-openCh:
-		return nil
-	case <-ctx.Done():
-		return ctx.Err()
-	}
+	return handshake.Wait(ctx)
 }
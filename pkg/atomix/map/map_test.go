@@ -6,13 +6,26 @@ package _map //nolint:golint
 
 import (
 	"context"
+	stderrors "errors"
+	"fmt"
 	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	api "github.com/atomix/atomix-api/go/atomix/primitive/map"
+	metaapi "github.com/atomix/atomix-api/go/atomix/primitive/meta"
+	clienterrors "github.com/atomix/atomix-go-client/pkg/atomix/errors"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
 	"github.com/atomix/atomix-go-client/pkg/atomix/util/test"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
+	metatime "github.com/atomix/atomix-go-framework/pkg/atomix/time"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestMapOperations(t *testing.T) {
@@ -41,7 +54,7 @@ func TestMapOperations(t *testing.T) {
 
 	kv, err := _map.Get(context.Background(), "foo")
 	assert.Error(t, err)
-	assert.True(t, errors.IsNotFound(err))
+	assert.True(t, clienterrors.IsNotFound(err))
 	assert.Nil(t, kv)
 
 	size, err := _map.Len(context.Background())
@@ -59,6 +72,12 @@ func TestMapOperations(t *testing.T) {
 	assert.Equal(t, "foo", kv.Key)
 	assert.Equal(t, "bar", string(kv.Value))
 
+	kv, err = _map.Get(context.Background(), "foo", WithHedging(time.Millisecond))
+	assert.NoError(t, err)
+	assert.NotNil(t, kv)
+	assert.Equal(t, "foo", kv.Key)
+	assert.Equal(t, "bar", string(kv.Value))
+
 	size, err = _map.Len(context.Background())
 	assert.NoError(t, err)
 	assert.Equal(t, 1, size)
@@ -111,11 +130,11 @@ func TestMapOperations(t *testing.T) {
 
 	_, err = _map.Put(context.Background(), "foo", []byte("bar"), IfMatch(kv1))
 	assert.Error(t, err)
-	assert.True(t, errors.IsConflict(err))
+	assert.True(t, clienterrors.IsConflict(err))
 
 	_, err = _map.Remove(context.Background(), "foo", IfMatch(meta.ObjectMeta{}))
 	assert.Error(t, err)
-	assert.True(t, errors.IsConflict(err))
+	assert.True(t, clienterrors.IsConflict(err))
 
 	removed, err := _map.Remove(context.Background(), "foo", IfMatch(kv2))
 	assert.NoError(t, err)
@@ -248,3 +267,1536 @@ func TestMapStreams(t *testing.T) {
 
 	assert.NoError(t, test.Stop())
 }
+
+func TestMapWatchOpen(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapWatchOpen",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestMapWatchOpen", conn)
+	assert.NoError(t, err)
+
+	ch := make(chan Event)
+	err = _map.Watch(context.TODO(), ch)
+	assert.NoError(t, err)
+
+	_, err = _map.Put(context.TODO(), "foo", []byte("bar"))
+	assert.NoError(t, err)
+
+	// The open marker completes the handshake and must not be delivered to the consumer
+	event := <-ch
+	assert.Equal(t, EventInsert, event.Type)
+	assert.Equal(t, "foo", event.Entry.Key)
+	assert.Equal(t, "bar", string(event.Entry.Value))
+
+	assert.NoError(t, _map.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+type testEventsClient struct {
+	grpc.ClientStream
+	responses []*api.EventsResponse
+	err       error
+}
+
+func (c *testEventsClient) Recv() (*api.EventsResponse, error) {
+	if len(c.responses) == 0 {
+		if c.err != nil {
+			return nil, c.err
+		}
+		return nil, io.EOF
+	}
+	response := c.responses[0]
+	c.responses = c.responses[1:]
+	return response, nil
+}
+
+type testMapClient struct {
+	api.MapServiceClient
+	events *testEventsClient
+	errors map[string]error
+}
+
+func (c *testMapClient) Get(ctx context.Context, request *api.GetRequest, opts ...grpc.CallOption) (*api.GetResponse, error) {
+	if err, ok := c.errors[request.Key]; ok {
+		return nil, err
+	}
+	return &api.GetResponse{
+		Entry: api.Entry{
+			Key: api.Key{
+				Key: request.Key,
+			},
+			Value: &api.Value{
+				Value: []byte(request.Key),
+			},
+		},
+	}, nil
+}
+
+func (c *testMapClient) Events(ctx context.Context, request *api.EventsRequest, opts ...grpc.CallOption) (api.MapService_EventsClient, error) {
+	return c.events, nil
+}
+
+func TestMapWatchOpenFailure(t *testing.T) {
+	// A stream that fails before it is opened fails the watch
+	m := &_map{
+		Client: primitive.NewClient(Type, "TestMapWatchOpenFailure", nil),
+		client: &testMapClient{
+			events: &testEventsClient{
+				err: status.Error(codes.Unavailable, "unavailable"),
+			},
+		},
+	}
+	ch := make(chan Event)
+	err := m.Watch(context.Background(), ch)
+	assert.True(t, clienterrors.IsUnavailable(err))
+	_, ok := <-ch
+	assert.False(t, ok)
+
+	// A stream that fails once it has been opened closes the channel
+	m = &_map{
+		Client: primitive.NewClient(Type, "TestMapWatchOpenFailure", nil),
+		client: &testMapClient{
+			events: &testEventsClient{
+				responses: []*api.EventsResponse{{}},
+				err:       status.Error(codes.Unavailable, "unavailable"),
+			},
+		},
+	}
+	ch = make(chan Event)
+	assert.NoError(t, m.Watch(context.Background(), ch))
+	_, ok = <-ch
+	assert.False(t, ok)
+}
+
+func TestMapEventTimestamp(t *testing.T) {
+	_map := &_map{
+		Client: primitive.NewClient(Type, "TestMapEventTimestamp", nil),
+		client: &testMapClient{
+			events: &testEventsClient{
+				responses: []*api.EventsResponse{
+					{},
+					{
+						Headers: primitiveapi.ResponseHeaders{
+							Timestamp: &metaapi.Timestamp{
+								Timestamp: &metaapi.Timestamp_LogicalTimestamp{
+									LogicalTimestamp: &metaapi.LogicalTimestamp{
+										Time: 10,
+									},
+								},
+							},
+						},
+						Event: api.Event{
+							Type: api.Event_INSERT,
+							Entry: api.Entry{
+								Key: api.Key{
+									Key: "foo",
+								},
+								Value: &api.Value{
+									Value: []byte("bar"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	ch := make(chan Event)
+	err := _map.Watch(context.TODO(), ch)
+	assert.NoError(t, err)
+
+	event := <-ch
+	assert.Equal(t, EventInsert, event.Type)
+	assert.Equal(t, "foo", event.Entry.Key)
+	assert.Equal(t, metatime.NewLogicalTimestamp(10), event.Timestamp)
+
+	_, ok := <-ch
+	assert.False(t, ok)
+}
+
+func TestMapTTL(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapTTL",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestMapTTL", conn)
+	assert.NoError(t, err)
+
+	_, err = _map.Put(context.Background(), "foo", []byte("bar"), WithTTL(0))
+	assert.Error(t, err)
+	assert.True(t, clienterrors.IsInvalid(err))
+
+	_, err = _map.Put(context.Background(), "foo", []byte("bar"), WithTTL(100*time.Millisecond))
+	assert.NoError(t, err)
+
+	kv, err := _map.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(kv.Value))
+
+	time.Sleep(500 * time.Millisecond)
+
+	// Expiration is driven by the state machine's clock, which advances as writes are applied
+	_, err = _map.Put(context.Background(), "baz", []byte("qux"))
+	assert.NoError(t, err)
+
+	_, err = _map.Get(context.Background(), "foo")
+	assert.Error(t, err)
+	assert.True(t, clienterrors.IsNotFound(err))
+
+	// A put that does not change the value of an entry without a TTL does not apply the TTL
+	_, err = _map.Put(context.Background(), "baz", []byte("qux"), WithTTL(time.Minute))
+	assert.True(t, clienterrors.IsNotSupported(err))
+
+	assert.NoError(t, _map.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+// testNoTTLMapClient is a map client whose server ignores TTLs
+type testNoTTLMapClient struct {
+	api.MapServiceClient
+}
+
+func (c *testNoTTLMapClient) Put(ctx context.Context, request *api.PutRequest, opts ...grpc.CallOption) (*api.PutResponse, error) {
+	return &api.PutResponse{
+		Entry: api.Entry{
+			Key: request.Entry.Key,
+			Value: &api.Value{
+				Value: request.Entry.Value.Value,
+			},
+		},
+	}, nil
+}
+
+func TestMapTTLNotSupported(t *testing.T) {
+	_map := &_map{
+		Client: primitive.NewClient(Type, "TestMapTTLNotSupported", nil),
+		client: &testNoTTLMapClient{},
+	}
+
+	_, err := _map.Put(context.Background(), "foo", []byte("bar"), WithTTL(time.Minute))
+	assert.True(t, clienterrors.IsNotSupported(err))
+
+	entry, err := _map.Put(context.Background(), "foo", []byte("bar"))
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(entry.Value))
+}
+
+func TestMapPutIfValue(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapPutIfValue",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestMapPutIfValue", conn)
+	assert.NoError(t, err)
+
+	// The value is not written if the key is missing
+	updated, err := _map.PutIfValue(context.Background(), "foo", []byte("bar"), []byte("baz"))
+	assert.NoError(t, err)
+	assert.False(t, updated)
+
+	_, err = _map.Get(context.Background(), "foo")
+	assert.True(t, clienterrors.IsNotFound(err))
+
+	kv, err := _map.Put(context.Background(), "foo", []byte("bar"))
+	assert.NoError(t, err)
+
+	// The value is not written if the current value does not match
+	updated, err = _map.PutIfValue(context.Background(), "foo", []byte("baz"), []byte("qux"))
+	assert.NoError(t, err)
+	assert.False(t, updated)
+
+	kv1, err := _map.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(kv1.Value))
+	assert.Equal(t, kv.Revision, kv1.Revision)
+
+	// The value is written if the current value matches
+	updated, err = _map.PutIfValue(context.Background(), "foo", []byte("bar"), []byte("baz"))
+	assert.NoError(t, err)
+	assert.True(t, updated)
+
+	kv2, err := _map.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "baz", string(kv2.Value))
+	assert.NotEqual(t, kv1.Revision, kv2.Revision)
+
+	assert.NoError(t, _map.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+func TestMapComputeIfAbsent(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapComputeIfAbsent",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestMapComputeIfAbsent", conn)
+	assert.NoError(t, err)
+
+	// Nothing is written if the function returns nil
+	kv, err := _map.ComputeIfAbsent(context.Background(), "foo", func() ([]byte, error) {
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, kv)
+	_, err = _map.Get(context.Background(), "foo")
+	assert.True(t, clienterrors.IsNotFound(err))
+
+	// The value is written if the key is absent
+	kv, err = _map.ComputeIfAbsent(context.Background(), "foo", func() ([]byte, error) {
+		return []byte("bar"), nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(kv.Value))
+
+	// The function is not called if the key is present
+	kv, err = _map.ComputeIfAbsent(context.Background(), "foo", func() ([]byte, error) {
+		t.Fatal("function called for present key")
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(kv.Value))
+
+	// The current entry is returned if the key is added concurrently
+	kv, err = _map.ComputeIfAbsent(context.Background(), "baz", func() ([]byte, error) {
+		_, err := _map.Put(context.Background(), "baz", []byte("concurrent"))
+		assert.NoError(t, err)
+		return []byte("computed"), nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "concurrent", string(kv.Value))
+
+	// Errors returned by the function are returned
+	_, err = _map.ComputeIfAbsent(context.Background(), "qux", func() ([]byte, error) {
+		return nil, errors.NewInvalid("invalid")
+	})
+	assert.True(t, clienterrors.IsInvalid(err))
+
+	// A panicking function yields an error and the map remains usable
+	_, err = _map.ComputeIfAbsent(context.Background(), "qux", func() ([]byte, error) {
+		panic("compute failed")
+	})
+	assert.True(t, clienterrors.IsPanic(err))
+	kv, err = _map.ComputeIfAbsent(context.Background(), "qux", func() ([]byte, error) {
+		return []byte("qux"), nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "qux", string(kv.Value))
+
+	assert.NoError(t, _map.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+func TestMapComputeIfPresent(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapComputeIfPresent",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestMapComputeIfPresent", conn)
+	assert.NoError(t, err)
+
+	// The function is not called if the key is absent
+	kv, err := _map.ComputeIfPresent(context.Background(), "foo", func(old []byte) ([]byte, error) {
+		t.Fatal("function called for absent key")
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, kv)
+	_, err = _map.Get(context.Background(), "foo")
+	assert.True(t, clienterrors.IsNotFound(err))
+
+	_, err = _map.Put(context.Background(), "foo", []byte("a"))
+	assert.NoError(t, err)
+
+	// The value is updated if the key is present
+	kv, err = _map.ComputeIfPresent(context.Background(), "foo", func(old []byte) ([]byte, error) {
+		return append(old, 'b'), nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "ab", string(kv.Value))
+
+	// The update is retried with the new value on conflicts
+	calls := 0
+	kv, err = _map.ComputeIfPresent(context.Background(), "foo", func(old []byte) ([]byte, error) {
+		calls++
+		if calls == 1 {
+			_, err := _map.Put(context.Background(), "foo", []byte("x"))
+			assert.NoError(t, err)
+		}
+		return append(old, 'c'), nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, "xc", string(kv.Value))
+
+	// The key is removed if the function returns nil
+	kv, err = _map.ComputeIfPresent(context.Background(), "foo", func(old []byte) ([]byte, error) {
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, kv)
+	_, err = _map.Get(context.Background(), "foo")
+	assert.True(t, clienterrors.IsNotFound(err))
+
+	// A panicking function yields an error and the value is not changed
+	_, err = _map.Put(context.Background(), "bar", []byte("a"))
+	assert.NoError(t, err)
+	_, err = _map.ComputeIfPresent(context.Background(), "bar", func(old []byte) ([]byte, error) {
+		panic("compute failed")
+	})
+	assert.True(t, clienterrors.IsPanic(err))
+	kv, err = _map.Get(context.Background(), "bar")
+	assert.NoError(t, err)
+	assert.Equal(t, "a", string(kv.Value))
+
+	assert.NoError(t, _map.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+func TestMapGetAll(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapGetAll",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestMapGetAll", conn)
+	assert.NoError(t, err)
+
+	values, err := _map.GetAll(context.Background(), []string{"foo", "bar"})
+	assert.NoError(t, err)
+	assert.Len(t, values, 0)
+
+	_, err = _map.Put(context.Background(), "foo", []byte("a"))
+	assert.NoError(t, err)
+	_, err = _map.Put(context.Background(), "baz", []byte("b"))
+	assert.NoError(t, err)
+
+	values, err = _map.GetAll(context.Background(), []string{"foo", "bar", "baz"})
+	assert.NoError(t, err)
+	assert.Len(t, values, 2)
+	assert.Equal(t, "a", string(values["foo"]))
+	assert.Equal(t, "b", string(values["baz"]))
+	_, ok := values["bar"]
+	assert.False(t, ok)
+
+	assert.NoError(t, _map.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+func TestMapGetAllErrors(t *testing.T) {
+	_map := &_map{
+		Client: primitive.NewClient(Type, "TestMapGetAllErrors", nil),
+		client: &testMapClient{
+			errors: map[string]error{
+				"bar": errors.Proto(errors.NewUnavailable("bar is unavailable")),
+				"baz": errors.Proto(errors.NewNotFound("baz not found")),
+				"qux": errors.Proto(errors.NewTimeout("qux timed out")),
+			},
+		},
+	}
+
+	values, err := _map.GetAll(context.Background(), []string{"foo", "bar", "baz", "qux"})
+	assert.Error(t, err)
+	assert.Len(t, values, 1)
+	assert.Equal(t, "foo", string(values["foo"]))
+
+	keyErrs, ok := err.(KeyErrors)
+	assert.True(t, ok)
+	assert.Len(t, keyErrs, 2)
+	assert.True(t, clienterrors.IsUnavailable(keyErrs["bar"]))
+	assert.True(t, clienterrors.IsTimeout(keyErrs["qux"]))
+	assert.Equal(t, "bar: bar is unavailable; qux: qux timed out", err.Error())
+}
+
+func TestMapGetNotFound(t *testing.T) {
+	_map := &_map{
+		Client: primitive.NewClient(Type, "TestMapGetNotFound", nil),
+		client: &testMapClient{
+			errors: map[string]error{
+				"bar": status.Error(codes.NotFound, "bar not found"),
+				"baz": status.Error(codes.Unavailable, "baz is unavailable"),
+			},
+		},
+	}
+
+	// Present keys return the entry
+	entry, err := _map.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(entry.Value))
+
+	// Missing keys are distinguishable with the standard errors.Is
+	entry, err = _map.Get(context.Background(), "bar")
+	assert.Nil(t, entry)
+	assert.True(t, stderrors.Is(err, clienterrors.ErrNotFound))
+	assert.True(t, clienterrors.Is(err, clienterrors.ErrNotFound))
+	assert.True(t, clienterrors.IsNotFound(err))
+
+	// Transport errors are not reported as missing keys
+	entry, err = _map.Get(context.Background(), "baz")
+	assert.Nil(t, entry)
+	assert.Error(t, err)
+	assert.False(t, clienterrors.Is(err, clienterrors.ErrNotFound))
+	assert.True(t, clienterrors.Is(err, clienterrors.ErrUnavailable))
+	assert.True(t, stderrors.Is(err, clienterrors.ErrUnavailable))
+}
+
+func TestMapGetNotFoundStandardIs(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapGetNotFoundStandardIs",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestMapGetNotFoundStandardIs", conn)
+	assert.NoError(t, err)
+
+	// The error returned by the server for a missing key matches the sentinel with the standard errors.Is
+	entry, err := _map.Get(context.TODO(), "foo")
+	assert.Nil(t, entry)
+	assert.True(t, stderrors.Is(err, clienterrors.ErrNotFound))
+	assert.False(t, stderrors.Is(err, clienterrors.ErrConflict))
+
+	_, err = _map.Put(context.TODO(), "foo", []byte("bar"), IfNotSet())
+	assert.NoError(t, err)
+	_, err = _map.Put(context.TODO(), "foo", []byte("baz"), IfNotSet())
+	assert.True(t, stderrors.Is(err, clienterrors.ErrAlreadyExists))
+
+	assert.NoError(t, _map.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+func TestMapMergeJSON(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapMergeJSON",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestMapMergeJSON", conn)
+	assert.NoError(t, err)
+
+	// The patch is written if the key is missing
+	err = _map.MergeJSON(context.Background(), "foo", map[string]interface{}{
+		"a": 1,
+		"b": map[string]interface{}{"c": 2, "d": 3},
+	})
+	assert.NoError(t, err)
+
+	kv, err := _map.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":1,"b":{"c":2,"d":3}}`, string(kv.Value))
+
+	// A shallow merge replaces top-level values
+	err = _map.MergeJSON(context.Background(), "foo", map[string]interface{}{
+		"b": map[string]interface{}{"c": 4},
+		"e": "f",
+	})
+	assert.NoError(t, err)
+
+	kv, err = _map.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":1,"b":{"c":4},"e":"f"}`, string(kv.Value))
+
+	// A deep merge merges nested objects
+	err = _map.MergeJSON(context.Background(), "foo", map[string]interface{}{
+		"b": map[string]interface{}{"d": 5},
+	}, WithDeepMerge())
+	assert.NoError(t, err)
+
+	kv, err = _map.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":1,"b":{"c":4,"d":5},"e":"f"}`, string(kv.Value))
+
+	// Values that are not JSON objects cannot be merged
+	_, err = _map.Put(context.Background(), "bar", []byte("[1, 2]"))
+	assert.NoError(t, err)
+	err = _map.MergeJSON(context.Background(), "bar", map[string]interface{}{"a": 1})
+	assert.True(t, clienterrors.IsInvalid(err))
+
+	_, err = _map.Put(context.Background(), "baz", []byte("baz"))
+	assert.NoError(t, err)
+	err = _map.MergeJSON(context.Background(), "baz", map[string]interface{}{"a": 1})
+	assert.True(t, clienterrors.IsInvalid(err))
+
+	assert.NoError(t, _map.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+type testMergeMapClient struct {
+	api.MapServiceClient
+	value     []byte
+	revision  uint64
+	conflicts []string
+	puts      int
+}
+
+func (c *testMergeMapClient) Get(ctx context.Context, request *api.GetRequest, opts ...grpc.CallOption) (*api.GetResponse, error) {
+	return &api.GetResponse{
+		Entry: api.Entry{
+			Key: api.Key{
+				ObjectMeta: metaapi.ObjectMeta{
+					Revision: &metaapi.Revision{
+						Num: metaapi.RevisionNum(c.revision),
+					},
+				},
+				Key: request.Key,
+			},
+			Value: &api.Value{
+				Value: c.value,
+			},
+		},
+	}, nil
+}
+
+func (c *testMergeMapClient) Put(ctx context.Context, request *api.PutRequest, opts ...grpc.CallOption) (*api.PutResponse, error) {
+	c.puts++
+	if len(c.conflicts) > 0 {
+		// Simulate a concurrent write to the key
+		c.value = []byte(c.conflicts[0])
+		c.conflicts = c.conflicts[1:]
+		c.revision++
+		return nil, status.Error(codes.FailedPrecondition, "revision mismatch")
+	}
+	c.value = request.Entry.Value.Value
+	c.revision++
+	return &api.PutResponse{
+		Entry: request.Entry,
+	}, nil
+}
+
+func TestMapMergeJSONConflict(t *testing.T) {
+	client := &testMergeMapClient{
+		value:     []byte(`{"a":1}`),
+		revision:  1,
+		conflicts: []string{`{"a":2}`, `{"a":2,"b":3}`},
+	}
+	_map := &_map{
+		Client: primitive.NewClient(Type, "TestMapMergeJSONConflict", nil),
+		client: client,
+	}
+
+	err := _map.MergeJSON(context.Background(), "foo", map[string]interface{}{"c": 4})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, client.puts)
+	assert.Equal(t, `{"a":2,"b":3,"c":4}`, string(client.value))
+}
+
+func TestMapDiff(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapDiff",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestMapDiff", conn)
+	assert.NoError(t, err)
+
+	// All desired keys are added to an empty map
+	toPut, toRemove, err := _map.Diff(context.Background(), map[string][]byte{"foo": []byte("a")})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"foo": []byte("a")}, toPut)
+	assert.Empty(t, toRemove)
+
+	_, err = _map.Put(context.Background(), "foo", []byte("a"))
+	assert.NoError(t, err)
+	_, err = _map.Put(context.Background(), "bar", []byte("b"))
+	assert.NoError(t, err)
+	_, err = _map.Put(context.Background(), "baz", []byte("c"))
+	assert.NoError(t, err)
+
+	// Unchanged keys are omitted, changed and new keys are put, and undesired keys are removed
+	toPut, toRemove, err = _map.Diff(context.Background(), map[string][]byte{
+		"foo": []byte("a"),
+		"bar": []byte("d"),
+		"qux": []byte("e"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"bar": []byte("d"), "qux": []byte("e")}, toPut)
+	assert.Equal(t, map[string][]byte{"baz": []byte("c")}, toRemove)
+
+	// An empty desired state removes all keys
+	toPut, toRemove, err = _map.Diff(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Empty(t, toPut)
+	assert.Len(t, toRemove, 3)
+
+	// A matching desired state produces no changes
+	toPut, toRemove, err = _map.Diff(context.Background(), map[string][]byte{
+		"foo": []byte("a"),
+		"bar": []byte("b"),
+		"baz": []byte("c"),
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, toPut)
+	assert.Empty(t, toRemove)
+
+	assert.NoError(t, _map.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+func TestMapReconcileTo(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapReconcileTo",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestMapReconcileTo", conn)
+	assert.NoError(t, err)
+
+	_, err = _map.Put(context.Background(), "foo", []byte("a"))
+	assert.NoError(t, err)
+	_, err = _map.Put(context.Background(), "bar", []byte("b"))
+	assert.NoError(t, err)
+	_, err = _map.Put(context.Background(), "baz", []byte("c"))
+	assert.NoError(t, err)
+
+	desired := map[string][]byte{
+		"foo": []byte("a"),
+		"bar": []byte("d"),
+		"qux": []byte("e"),
+	}
+
+	// A dry run returns the planned changes without applying them
+	result, err := _map.ReconcileTo(context.Background(), desired, WithDryRun())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"bar": []byte("d"), "qux": []byte("e")}, result.Put)
+	assert.Equal(t, map[string][]byte{"baz": []byte("c")}, result.Removed)
+
+	values, err := _map.GetAll(context.Background(), []string{"foo", "bar", "baz", "qux"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"foo": []byte("a"), "bar": []byte("b"), "baz": []byte("c")}, values)
+
+	// Reconciling applies the planned changes
+	result, err = _map.ReconcileTo(context.Background(), desired, WithParallelism(2))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"bar": []byte("d"), "qux": []byte("e")}, result.Put)
+	assert.Equal(t, map[string][]byte{"baz": []byte("c")}, result.Removed)
+
+	values, err = _map.GetAll(context.Background(), []string{"foo", "bar", "baz", "qux"})
+	assert.NoError(t, err)
+	assert.Equal(t, desired, values)
+
+	// The map has converged to the desired state
+	result, err = _map.ReconcileTo(context.Background(), desired)
+	assert.NoError(t, err)
+	assert.Empty(t, result.Put)
+	assert.Empty(t, result.Removed)
+
+	_, err = _map.ReconcileTo(context.Background(), desired, WithParallelism(0))
+	assert.True(t, clienterrors.IsInvalid(err))
+
+	assert.NoError(t, _map.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+type testStreamingEventsClient struct {
+	grpc.ClientStream
+	ctx       context.Context
+	responses chan *api.EventsResponse
+}
+
+func (c *testStreamingEventsClient) Recv() (*api.EventsResponse, error) {
+	select {
+	case response := <-c.responses:
+		return response, nil
+	case <-c.ctx.Done():
+		return nil, c.ctx.Err()
+	}
+}
+
+type testStreamingMapClient struct {
+	api.MapServiceClient
+	streams chan *testStreamingEventsClient
+}
+
+func (c *testStreamingMapClient) Events(ctx context.Context, request *api.EventsRequest, opts ...grpc.CallOption) (api.MapService_EventsClient, error) {
+	stream := &testStreamingEventsClient{
+		ctx:       ctx,
+		responses: make(chan *api.EventsResponse, 1),
+	}
+	stream.responses <- &api.EventsResponse{}
+	c.streams <- stream
+	return stream, nil
+}
+
+func newTestInsertResponse(key string) *api.EventsResponse {
+	return &api.EventsResponse{
+		Event: api.Event{
+			Type: api.Event_INSERT,
+			Entry: api.Entry{
+				Key: api.Key{
+					Key: key,
+				},
+				Value: &api.Value{
+					Value: []byte(key),
+				},
+			},
+		},
+	}
+}
+
+func TestMapSharedWatch(t *testing.T) {
+	client := &testStreamingMapClient{
+		streams: make(chan *testStreamingEventsClient, 2),
+	}
+	_map := &_map{
+		Client: primitive.NewClient(Type, "TestMapSharedWatch", nil),
+		client: client,
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ch1 := make(chan Event, 10)
+	assert.NoError(t, _map.Watch(ctx1, ch1, WithSharedStream()))
+
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	ch2 := make(chan Event, 10)
+	assert.NoError(t, _map.Watch(ctx2, ch2, WithSharedStream()))
+
+	// Both subscribers share a single upstream stream
+	stream := <-client.streams
+	assert.Len(t, client.streams, 0)
+
+	for _, key := range []string{"foo", "bar", "baz"} {
+		stream.responses <- newTestInsertResponse(key)
+	}
+	for _, ch := range []chan Event{ch1, ch2} {
+		for _, key := range []string{"foo", "bar", "baz"} {
+			event := <-ch
+			assert.Equal(t, EventInsert, event.Type)
+			assert.Equal(t, key, event.Entry.Key)
+		}
+	}
+
+	// Removing a subscriber does not close the upstream stream
+	cancel1()
+	_, ok := <-ch1
+	assert.False(t, ok)
+
+	stream.responses <- newTestInsertResponse("qux")
+	event := <-ch2
+	assert.Equal(t, "qux", event.Entry.Key)
+	assert.NoError(t, stream.ctx.Err())
+
+	// Removing the last subscriber closes the upstream stream
+	cancel2()
+	_, ok = <-ch2
+	assert.False(t, ok)
+	select {
+	case <-stream.ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("upstream stream was not closed")
+	}
+
+	// A new subscriber opens a new upstream stream
+	ctx3, cancel3 := context.WithCancel(context.Background())
+	defer cancel3()
+	ch3 := make(chan Event)
+	assert.NoError(t, _map.Watch(ctx3, ch3, WithSharedStream()))
+	stream = <-client.streams
+	stream.responses <- newTestInsertResponse("foo")
+	event = <-ch3
+	assert.Equal(t, "foo", event.Entry.Key)
+}
+
+func TestMapWatchHistory(t *testing.T) {
+	client := &testStreamingMapClient{
+		streams: make(chan *testStreamingEventsClient, 1),
+	}
+	_map := &_map{
+		Client: primitive.NewClient(Type, "TestMapWatchHistory", nil),
+		client: client,
+		mux: watchMux{
+			historySize: 2,
+		},
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	defer cancel1()
+	ch1 := make(chan Event, 10)
+	assert.NoError(t, _map.Watch(ctx1, ch1, WithSharedStream()))
+	stream := <-client.streams
+
+	for _, key := range []string{"foo", "bar", "baz"} {
+		stream.responses <- newTestInsertResponse(key)
+	}
+	for _, key := range []string{"foo", "bar", "baz"} {
+		event := <-ch1
+		assert.Equal(t, key, event.Entry.Key)
+	}
+
+	// A late subscriber receives the most recent buffered events followed by live events
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+	ch2 := make(chan Event)
+	assert.NoError(t, _map.Watch(ctx2, ch2, WithHistory()))
+
+	// A late subscriber that does not request history receives only live events
+	ctx3, cancel3 := context.WithCancel(context.Background())
+	defer cancel3()
+	ch3 := make(chan Event, 10)
+	assert.NoError(t, _map.Watch(ctx3, ch3, WithSharedStream()))
+
+	stream.responses <- newTestInsertResponse("qux")
+	for _, key := range []string{"bar", "baz", "qux"} {
+		select {
+		case event := <-ch2:
+			assert.Equal(t, key, event.Entry.Key)
+		case <-time.After(5 * time.Second):
+			t.Fatal("event was not received")
+		}
+	}
+	event := <-ch3
+	assert.Equal(t, "qux", event.Entry.Key)
+	assert.Len(t, client.streams, 0)
+}
+
+func TestMapWatchNotShared(t *testing.T) {
+	client := &testStreamingMapClient{
+		streams: make(chan *testStreamingEventsClient, 2),
+	}
+	_map := &_map{
+		Client: primitive.NewClient(Type, "TestMapWatchNotShared", nil),
+		client: client,
+	}
+
+	// Watches without the WithSharedStream option each open their own stream
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, _map.Watch(ctx, make(chan Event)))
+	assert.NoError(t, _map.Watch(ctx, make(chan Event)))
+	assert.Len(t, client.streams, 2)
+}
+
+func TestMapSharedWatchSlowSubscriber(t *testing.T) {
+	client := &testStreamingMapClient{
+		streams: make(chan *testStreamingEventsClient, 1),
+	}
+	_map := &_map{
+		Client: primitive.NewClient(Type, "TestMapSharedWatchSlowSubscriber", nil),
+		client: client,
+	}
+
+	// The first subscriber never consumes its channel
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ch1 := make(chan Event)
+	assert.NoError(t, _map.Watch(ctx1, ch1, WithSharedStream()))
+
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+	ch2 := make(chan Event)
+	assert.NoError(t, _map.Watch(ctx2, ch2, WithSharedStream()))
+	stream := <-client.streams
+
+	// The slow subscriber does not delay delivery to the other subscriber
+	for _, key := range []string{"foo", "bar", "baz"} {
+		stream.responses <- newTestInsertResponse(key)
+		select {
+		case event := <-ch2:
+			assert.Equal(t, key, event.Entry.Key)
+		case <-time.After(5 * time.Second):
+			t.Fatal("event was not received")
+		}
+	}
+
+	// The slow subscriber's queued events are delivered in order once it consumes its channel
+	for _, key := range []string{"foo", "bar", "baz"} {
+		event := <-ch1
+		assert.Equal(t, key, event.Entry.Key)
+	}
+	cancel1()
+	_, ok := <-ch1
+	assert.False(t, ok)
+}
+
+func TestMapSharedWatchOpen(t *testing.T) {
+	var mux watchMux
+	var opens int32
+	release := make(chan error)
+	open := func(ctx context.Context, ch chan<- Event, opts ...WatchOption) error {
+		atomic.AddInt32(&opens, 1)
+		if err := <-release; err != nil {
+			return err
+		}
+		go func() {
+			<-ctx.Done()
+			close(ch)
+		}()
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	subscribed := make(chan error, 2)
+	go func() {
+		subscribed <- mux.subscribe(ctx, make(chan Event), open, false)
+	}()
+	for atomic.LoadInt32(&opens) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// A subscriber does not block on the mux while the stream is being opened
+	timeoutCtx, timeoutCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer timeoutCancel()
+	assert.True(t, clienterrors.IsTimeout(mux.subscribe(timeoutCtx, make(chan Event), open, false)))
+
+	// Concurrent subscribers share the attempt to open the stream
+	go func() {
+		subscribed <- mux.subscribe(ctx, make(chan Event), open, false)
+	}()
+	release <- nil
+	assert.NoError(t, <-subscribed)
+	assert.NoError(t, <-subscribed)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&opens))
+
+	// A failure to open the stream is returned to the subscriber
+	cancel()
+	for {
+		mux.mu.Lock()
+		closed := mux.stream == nil
+		mux.mu.Unlock()
+		if closed {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	go func() {
+		release <- clienterrors.NewUnavailable("open failed")
+	}()
+	assert.True(t, clienterrors.IsUnavailable(mux.subscribe(context.Background(), make(chan Event), open, false)))
+}
+
+func TestMapSharedWatchQueueFull(t *testing.T) {
+	client := &testStreamingMapClient{
+		streams: make(chan *testStreamingEventsClient, 1),
+	}
+	_map := &_map{
+		Client: primitive.NewClient(Type, "TestMapSharedWatchQueueFull", nil),
+		client: client,
+		mux: watchMux{
+			queueSize: 2,
+		},
+	}
+
+	// The first subscriber does not consume its channel until its queue is full
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	defer cancel1()
+	ch1 := make(chan Event)
+	assert.NoError(t, _map.Watch(ctx1, ch1, WithSharedStream()))
+
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+	ch2 := make(chan Event)
+	assert.NoError(t, _map.Watch(ctx2, ch2, WithSharedStream()))
+	stream := <-client.streams
+
+	// The first subscriber is closed once its queue overflows, and other subscribers are unaffected
+	for _, key := range []string{"foo", "bar", "baz", "qux"} {
+		stream.responses <- newTestInsertResponse(key)
+		select {
+		case event := <-ch2:
+			assert.Equal(t, key, event.Entry.Key)
+		case <-time.After(5 * time.Second):
+			t.Fatal("event was not received")
+		}
+	}
+
+	// The events queued before the overflow are delivered in order before the channel is closed
+	var keys []string
+	for event := range ch1 {
+		keys = append(keys, event.Entry.Key)
+	}
+	assert.True(t, len(keys) >= 2 && len(keys) < 4)
+	assert.Equal(t, []string{"foo", "bar", "baz", "qux"}[:len(keys)], keys)
+	assert.NoError(t, stream.ctx.Err())
+}
+
+type testEntriesClient struct {
+	grpc.ClientStream
+	responses []*api.EntriesResponse
+}
+
+func (c *testEntriesClient) Recv() (*api.EntriesResponse, error) {
+	if len(c.responses) == 0 {
+		return nil, io.EOF
+	}
+	response := c.responses[0]
+	c.responses = c.responses[1:]
+	return response, nil
+}
+
+// testEntriesMapClient is a map client that streams entries for the given keys in order
+type testEntriesMapClient struct {
+	api.MapServiceClient
+	keys []string
+}
+
+func (c *testEntriesMapClient) Entries(ctx context.Context, request *api.EntriesRequest, opts ...grpc.CallOption) (api.MapService_EntriesClient, error) {
+	stream := &testEntriesClient{}
+	for _, key := range c.keys {
+		stream.responses = append(stream.responses, &api.EntriesResponse{
+			Entry: newTestInsertResponse(key).Event.Entry,
+		})
+	}
+	return stream, nil
+}
+
+func TestMapEntriesSorted(t *testing.T) {
+	keys := []string{"foo", "bar", "qux", "baz", "a", "foobar"}
+	_map := &_map{
+		Client: primitive.NewClient(Type, "TestMapEntriesSorted", nil),
+		client: &testEntriesMapClient{keys: keys},
+	}
+
+	// Entries are delivered in backend order by default
+	ch := make(chan Entry)
+	assert.NoError(t, _map.Entries(context.Background(), ch))
+	var received []string
+	for entry := range ch {
+		received = append(received, entry.Key)
+	}
+	assert.Equal(t, keys, received)
+
+	ch = make(chan Entry)
+	assert.NoError(t, _map.Entries(context.Background(), ch, WithSortedKeys()))
+	received = nil
+	for entry := range ch {
+		assert.Equal(t, entry.Key, string(entry.Value))
+		received = append(received, entry.Key)
+	}
+	assert.Equal(t, []string{"a", "bar", "baz", "foo", "foobar", "qux"}, received)
+}
+
+func TestMapGetAndWatch(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapGetAndWatch",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestMapGetAndWatch", conn)
+	assert.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, err := _map.Put(context.Background(), fmt.Sprintf("key-%d", i), []byte("a"))
+		assert.NoError(t, err)
+	}
+
+	// Write concurrently with the snapshot
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			key := fmt.Sprintf("key-%d", i%10)
+			if i%3 == 0 {
+				_, _ = _map.Remove(context.Background(), key)
+			} else {
+				_, err := _map.Put(context.Background(), key, []byte(fmt.Sprintf("%d", i)))
+				assert.NoError(t, err)
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan Event)
+	snapshot, err := _map.GetAndWatch(ctx, ch)
+	assert.NoError(t, err)
+
+	// Apply the events to the snapshot, checking each event follows the state it is applied to
+	state := make(map[string]Entry)
+	for key, entry := range snapshot.Entries {
+		state[key] = entry
+	}
+	<-done
+	expected := make(map[string]Entry)
+	entries := make(chan Entry)
+	assert.NoError(t, _map.Entries(context.Background(), entries))
+	for entry := range entries {
+		expected[entry.Key] = entry
+	}
+
+	for !equalEntries(state, expected) {
+		select {
+		case event := <-ch:
+			current, ok := state[event.Entry.Key]
+			switch event.Type {
+			case EventRemove:
+				assert.True(t, ok)
+				assert.Equal(t, current.Revision, event.Entry.Revision)
+				delete(state, event.Entry.Key)
+			case EventInsert, EventUpdate:
+				if ok {
+					assert.Greater(t, int64(event.Entry.Revision), int64(current.Revision))
+				} else {
+					assert.Greater(t, int64(event.Entry.Revision), int64(snapshot.Revision))
+				}
+				state[event.Entry.Key] = event.Entry
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected %v, got %v", expected, state)
+		}
+	}
+
+	// No events follow the final state
+	select {
+	case event := <-ch:
+		t.Errorf("unexpected event %v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cancel()
+	for range ch {
+	}
+	assert.NoError(t, _map.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+// equalEntries returns whether the given maps contain the same keys at the same revisions
+func equalEntries(a, b map[string]Entry) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, entry := range a {
+		if other, ok := b[key]; !ok || other.Revision != entry.Revision {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMapSnapshotFilter(t *testing.T) {
+	event := func(eventType EventType, key string, revision meta.Revision) Event {
+		return Event{
+			Type: eventType,
+			Entry: Entry{
+				ObjectMeta: meta.ObjectMeta{Revision: revision},
+				Key:        key,
+			},
+		}
+	}
+
+	filter := newSnapshotFilter(Snapshot{
+		Entries: map[string]Entry{
+			"foo": {ObjectMeta: meta.ObjectMeta{Revision: 3}, Key: "foo"},
+			"bar": {ObjectMeta: meta.ObjectMeta{Revision: 5}, Key: "bar"},
+		},
+		Revision: 5,
+	})
+
+	// Changes reflected in the snapshot are dropped
+	assert.False(t, filter.accept(event(EventInsert, "foo", 3)))
+	assert.False(t, filter.accept(event(EventInsert, "baz", 2)))
+	assert.False(t, filter.accept(event(EventRemove, "baz", 2)))
+	assert.False(t, filter.accept(event(EventUpdate, "bar", 5)))
+
+	// Changes following the snapshot are delivered once
+	assert.True(t, filter.accept(event(EventUpdate, "foo", 6)))
+	assert.False(t, filter.accept(event(EventUpdate, "foo", 6)))
+	assert.True(t, filter.accept(event(EventRemove, "bar", 5)))
+	assert.False(t, filter.accept(event(EventRemove, "bar", 5)))
+	assert.True(t, filter.accept(event(EventInsert, "bar", 7)))
+	assert.True(t, filter.accept(event(EventInsert, "baz", 8)))
+	assert.True(t, filter.accept(event(EventRemove, "baz", 8)))
+}
+
+func newTestEvent(t api.Event_Type, key string, value string) *api.EventsResponse {
+	return &api.EventsResponse{
+		Event: api.Event{
+			Type: t,
+			Entry: api.Entry{
+				Key: api.Key{
+					Key: key,
+				},
+				Value: &api.Value{
+					Value: []byte(value),
+				},
+			},
+		},
+	}
+}
+
+func TestMapWatchPrevValues(t *testing.T) {
+	_map := &_map{
+		Client: primitive.NewClient(Type, "TestMapWatchPrevValues", nil),
+		client: &testMapClient{
+			events: &testEventsClient{
+				responses: []*api.EventsResponse{
+					{},
+					newTestEvent(api.Event_REPLAY, "foo", "a"),
+					newTestEvent(api.Event_UPDATE, "foo", "b"),
+					newTestEvent(api.Event_INSERT, "bar", "c"),
+					newTestEvent(api.Event_UPDATE, "bar", "d"),
+					newTestEvent(api.Event_UPDATE, "baz", "e"),
+					newTestEvent(api.Event_REMOVE, "foo", "b"),
+					newTestEvent(api.Event_INSERT, "foo", "f"),
+					newTestEvent(api.Event_UPDATE, "foo", "g"),
+				},
+			},
+		},
+	}
+
+	ch := make(chan Event)
+	err := _map.Watch(context.TODO(), ch, WithReplay(), WithPrevValues())
+	assert.NoError(t, err)
+
+	// The replayed value is the previous value of the first update
+	event := <-ch
+	assert.Equal(t, EventReplay, event.Type)
+	assert.Nil(t, event.PrevValue)
+	event = <-ch
+	assert.Equal(t, EventUpdate, event.Type)
+	assert.Equal(t, "b", string(event.Entry.Value))
+	assert.Equal(t, "a", string(event.PrevValue))
+
+	// Inserted values are tracked
+	event = <-ch
+	assert.Equal(t, EventInsert, event.Type)
+	assert.Nil(t, event.PrevValue)
+	event = <-ch
+	assert.Equal(t, EventUpdate, event.Type)
+	assert.Equal(t, "c", string(event.PrevValue))
+
+	// The previous value of a key the watch has not seen is unknown
+	event = <-ch
+	assert.Equal(t, EventUpdate, event.Type)
+	assert.Nil(t, event.PrevValue)
+
+	// Removed keys are forgotten
+	event = <-ch
+	assert.Equal(t, EventRemove, event.Type)
+	event = <-ch
+	assert.Equal(t, EventInsert, event.Type)
+	assert.Nil(t, event.PrevValue)
+	event = <-ch
+	assert.Equal(t, EventUpdate, event.Type)
+	assert.Equal(t, "f", string(event.PrevValue))
+
+	_, ok := <-ch
+	assert.False(t, ok)
+}
+
+func TestMapWatchNoPrevValues(t *testing.T) {
+	_map := &_map{
+		Client: primitive.NewClient(Type, "TestMapWatchNoPrevValues", nil),
+		client: &testMapClient{
+			events: &testEventsClient{
+				responses: []*api.EventsResponse{
+					{},
+					newTestEvent(api.Event_INSERT, "foo", "a"),
+					newTestEvent(api.Event_UPDATE, "foo", "b"),
+				},
+			},
+		},
+	}
+
+	ch := make(chan Event)
+	err := _map.Watch(context.TODO(), ch)
+	assert.NoError(t, err)
+
+	<-ch
+	event := <-ch
+	assert.Equal(t, EventUpdate, event.Type)
+	assert.Nil(t, event.PrevValue)
+}
+
+func TestMapEntriesCancel(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapEntriesCancel",
+	}
+
+	rsm := test.NewRSMTest()
+	assert.NoError(t, rsm.Start())
+
+	conn, err := rsm.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestMapEntriesCancel", conn)
+	assert.NoError(t, err)
+
+	for _, key := range []string{"foo", "bar", "baz", "qux"} {
+		_, err = _map.Put(context.Background(), key, []byte(key))
+		assert.NoError(t, err)
+	}
+
+	for _, opts := range [][]EntriesOption{nil, {WithSortedKeys()}} {
+		// Cancelling the context mid-iteration ends the stream and closes the channel although the consumer
+		// has stopped reading
+		check := test.NewLeakCheck()
+		ctx, cancel := context.WithCancel(context.Background())
+		ch := make(chan Entry)
+		assert.NoError(t, _map.Entries(ctx, ch, opts...))
+		<-ch
+		check.AssertCancel(t, cancel, 5*time.Second)
+		_, ok := <-ch
+		assert.False(t, ok)
+	}
+
+	assert.NoError(t, _map.Close(context.Background()))
+	assert.NoError(t, rsm.Stop())
+}
+
+// testVersionMapClient is a map client whose entries have fixed revisions
+type testVersionMapClient struct {
+	api.MapServiceClient
+	revisions map[string]uint64
+}
+
+func (c *testVersionMapClient) Get(ctx context.Context, request *api.GetRequest, opts ...grpc.CallOption) (*api.GetResponse, error) {
+	revision, ok := c.revisions[request.Key]
+	if !ok {
+		return nil, errors.NewNotFound("key %s not found", request.Key)
+	}
+	return &api.GetResponse{
+		Entry: api.Entry{
+			Key: api.Key{
+				ObjectMeta: metaapi.ObjectMeta{
+					Revision: &metaapi.Revision{
+						Num: metaapi.RevisionNum(revision),
+					},
+				},
+				Key: request.Key,
+			},
+			Value: &api.Value{
+				Value: []byte("bar"),
+			},
+		},
+	}, nil
+}
+
+func TestMapGetVersion(t *testing.T) {
+	_map := &_map{
+		Client: primitive.NewClient(Type, "TestMapGetVersion", nil),
+		client: &testVersionMapClient{
+			revisions: map[string]uint64{"foo": 42},
+		},
+	}
+
+	// The version is the revision of the entry
+	version, err := _map.GetVersion(context.TODO(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, Version(42), version)
+
+	_, err = _map.GetVersion(context.TODO(), "bar")
+	assert.True(t, clienterrors.IsNotFound(err))
+}
+
+func TestMapIfVersion(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapIfVersion",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestMapIfVersion", conn)
+	assert.NoError(t, err)
+
+	entry, err := _map.Put(context.Background(), "foo", []byte("bar"))
+	assert.NoError(t, err)
+	version, err := _map.GetVersion(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, Version(entry.Revision), version)
+
+	// An update conditional on the current version succeeds and changes the version
+	_, err = _map.Put(context.Background(), "foo", []byte("baz"), IfVersion(version))
+	assert.NoError(t, err)
+	updated, err := _map.GetVersion(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.NotEqual(t, version, updated)
+
+	// An update conditional on a stale version fails
+	_, err = _map.Put(context.Background(), "foo", []byte("qux"), IfVersion(version))
+	assert.True(t, clienterrors.IsConflict(err))
+	_, err = _map.Remove(context.Background(), "foo", IfVersion(version))
+	assert.True(t, clienterrors.IsConflict(err))
+	_, err = _map.Remove(context.Background(), "foo", IfVersion(updated))
+	assert.NoError(t, err)
+
+	assert.NoError(t, _map.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
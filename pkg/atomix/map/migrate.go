@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package _map //nolint:golint
+
+import (
+	"bytes"
+	"context"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
+	"github.com/atomix/atomix-go-client/pkg/atomix/value"
+)
+
+// MigrateOption is an option for the MigrateValue function
+type MigrateOption interface {
+	applyMigrate(options *migrateOptions)
+}
+
+// migrateOptions is a set of MigrateValue options
+type migrateOptions struct {
+	deleteSource bool
+}
+
+// WithDeleteSource clears the source Value once its contents have been written to the target Map
+func WithDeleteSource() MigrateOption {
+	return deleteSourceOption{}
+}
+
+type deleteSourceOption struct{}
+
+func (o deleteSourceOption) applyMigrate(options *migrateOptions) {
+	options.deleteSource = true
+}
+
+// MigrateValue migrates the contents of the given Value to the given key in the target Map
+// The value is written only if the key is not present, and the write is verified by reading the key back.
+// If the WithDeleteSource option is provided, the source Value is cleared once the write has been verified;
+// the source is cleared only if it has not changed since it was read.
+// Migration is idempotent while the source is not cleared: if the key already holds the value, it is not
+// written again. If the key holds a different value, an error matching errors.ErrConflict is returned. If
+// the source has never been set, an error matching errors.ErrNotFound is returned.
+// A cleared Value cannot be told apart from a Value set to an empty value, and its revision does not prove
+// that it was cleared by an earlier migration, so an empty source is migrated as an empty value. Re-running
+// a migration after WithDeleteSource has cleared the source therefore returns an error matching
+// errors.ErrConflict if the key holds a non-empty value.
+func MigrateValue(ctx context.Context, source value.Value, target Map, key string, opts ...MigrateOption) (*Entry, error) {
+	options := migrateOptions{}
+	for _, opt := range opts {
+		opt.applyMigrate(&options)
+	}
+
+	contents, meta, err := source.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// A source that has never been set has no revision
+	if meta.Revision == 0 {
+		return nil, errors.NewNotFound("value %s is not set", source.Name())
+	}
+
+	// An empty source may have been cleared by an earlier migration, but as nothing proves it, the
+	// existing entry is verified against the empty value like any other value
+	if len(contents) == 0 {
+		entry, err := target.Get(ctx, key)
+		if err == nil && len(entry.Value) > 0 {
+			return nil, errors.NewConflict("value %s is empty but key %s in map %s holds a value", source.Name(), key, target.Name())
+		}
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+
+	if _, err := target.Put(ctx, key, contents, IfNotSet()); err != nil && !errors.IsAlreadyExists(err) && !errors.IsConflict(err) {
+		return nil, err
+	}
+
+	entry, err := verifyMigration(ctx, target, key, contents)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.deleteSource {
+		if err := source.Clear(ctx, value.IfMatch(meta)); err != nil {
+			return nil, err
+		}
+	}
+	return entry, nil
+}
+
+// verifyMigration reads the given key back from the map and verifies it holds the given value
+func verifyMigration(ctx context.Context, target Map, key string, expected []byte) (*Entry, error) {
+	entry, err := target.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(entry.Value, expected) {
+		return nil, errors.NewConflict("key %s in map %s holds a different value", key, target.Name())
+	}
+	return entry, nil
+}
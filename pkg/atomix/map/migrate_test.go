@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package _map //nolint:golint
+
+import (
+	"context"
+	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
+	"github.com/atomix/atomix-go-client/pkg/atomix/util/test"
+	"github.com/atomix/atomix-go-client/pkg/atomix/value"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMigrateValue(t *testing.T) {
+	valueID := primitiveapi.PrimitiveId{
+		Type:      value.Type.String(),
+		Namespace: "test",
+		Name:      "TestMigrateValue",
+	}
+	mapID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMigrateValue",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	valueConn, err := test.CreateProxy(valueID)
+	assert.NoError(t, err)
+	mapConn, err := test.CreateProxy(mapID)
+	assert.NoError(t, err)
+
+	source, err := value.New(context.TODO(), "TestMigrateValue", valueConn)
+	assert.NoError(t, err)
+	target, err := New(context.TODO(), "TestMigrateValue", mapConn)
+	assert.NoError(t, err)
+
+	// Migrating an unset value fails
+	_, err = MigrateValue(context.Background(), source, target, "foo")
+	assert.True(t, errors.IsNotFound(err))
+
+	_, err = source.Set(context.Background(), []byte("bar"))
+	assert.NoError(t, err)
+
+	// The value is written under the given key
+	entry, err := MigrateValue(context.Background(), source, target, "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", entry.Key)
+	assert.Equal(t, "bar", string(entry.Value))
+	revision := entry.Revision
+
+	// Re-running the migration does not write the key again
+	entry, err = MigrateValue(context.Background(), source, target, "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(entry.Value))
+	assert.Equal(t, revision, entry.Revision)
+
+	// The source is cleared once the write has been verified
+	entry, err = MigrateValue(context.Background(), source, target, "foo", WithDeleteSource())
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(entry.Value))
+	contents, _, err := source.Get(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, contents, 0)
+
+	// An empty source is not assumed to have been migrated, so re-running the migration after the source
+	// has been cleared reports the key's value as a conflict and leaves the key unchanged
+	_, err = MigrateValue(context.Background(), source, target, "foo", WithDeleteSource())
+	assert.True(t, errors.IsConflict(err))
+	entry, err = target.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(entry.Value))
+	assert.Equal(t, revision, entry.Revision)
+
+	// A key holding a different value is not overwritten
+	_, err = source.Set(context.Background(), []byte("baz"))
+	assert.NoError(t, err)
+	_, err = MigrateValue(context.Background(), source, target, "foo", WithDeleteSource())
+	assert.True(t, errors.IsConflict(err))
+	entry, err = target.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(entry.Value))
+	contents, _, err = source.Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "baz", string(contents))
+
+	// A value set to an empty value is migrated as an empty value rather than treated as unset
+	_, err = source.Set(context.Background(), []byte{})
+	assert.NoError(t, err)
+	entry, err = MigrateValue(context.Background(), source, target, "qux")
+	assert.NoError(t, err)
+	assert.Equal(t, "qux", entry.Key)
+	assert.Len(t, entry.Value, 0)
+	revision = entry.Revision
+
+	// An empty source matches a key holding an empty value
+	entry, err = MigrateValue(context.Background(), source, target, "qux")
+	assert.NoError(t, err)
+	assert.Len(t, entry.Value, 0)
+	assert.Equal(t, revision, entry.Revision)
+
+	// An empty source set while the key holds a value is a conflict
+	_, err = MigrateValue(context.Background(), source, target, "foo")
+	assert.True(t, errors.IsConflict(err))
+
+	assert.NoError(t, source.Close(context.Background()))
+	assert.NoError(t, target.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
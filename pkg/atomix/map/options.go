@@ -9,6 +9,7 @@ import (
 	metaapi "github.com/atomix/atomix-api/go/atomix/primitive/meta"
 	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
+	"time"
 )
 
 // Option is a map option
@@ -18,7 +19,46 @@ type Option interface {
 }
 
 // newMapOptions is map options
-type newMapOptions struct{}
+type newMapOptions struct {
+	watchHistory   int
+	watchQueueSize int
+}
+
+// WithWatchHistory sets the number of recent events retained by the map's shared watch stream
+// Retained events can be delivered to late subscribers with the WithHistory watch option. Events are
+// retained only while the shared stream is open, i.e. while at least one watch with the WithSharedStream
+// option is open.
+func WithWatchHistory(size int) Option {
+	return watchHistoryOption{size: size}
+}
+
+// watchHistoryOption is an option for the watch history size
+type watchHistoryOption struct {
+	primitive.EmptyOption
+	size int
+}
+
+func (o watchHistoryOption) applyNewMap(options *newMapOptions) {
+	options.watchHistory = o.size
+}
+
+// WithWatchQueueSize sets the maximum number of events queued for each watch sharing the map's watch stream
+// A watch with the WithSharedStream option whose queue is full is closed: it is removed from the shared
+// stream and its channel is closed once the events already queued have been delivered, so a closed
+// channel may mean the watch fell behind. Defaults to 1000 events.
+func WithWatchQueueSize(size int) Option {
+	return watchQueueSizeOption{size: size}
+}
+
+// watchQueueSizeOption is an option for the shared watch queue size
+type watchQueueSizeOption struct {
+	primitive.EmptyOption
+	size int
+}
+
+func (o watchQueueSizeOption) applyNewMap(options *newMapOptions) {
+	options.watchQueueSize = o.size
+}
 
 // PutOption is an option for the Put method
 type PutOption interface {
@@ -37,6 +77,13 @@ func IfMatch(object meta.Object) MatchOption {
 	return MatchOption{object: object}
 }
 
+// IfVersion sets the required version for optimistic concurrency control
+// The version is typically obtained with GetVersion; the operation fails with an error matching
+// errors.ErrConflict if the entry has been modified since.
+func IfVersion(version Version) MatchOption {
+	return IfMatch(meta.ObjectMeta{Revision: meta.Revision(version)})
+}
+
 // MatchOption is an implementation of PutOption and RemoveOption to specify the version for concurrency control
 type MatchOption struct {
 	PutOption
@@ -93,12 +140,134 @@ func (o NotSetOption) afterPut(response *api.PutResponse) {
 
 }
 
+// WithTTL returns a Put option that expires the entry once the given duration has elapsed
+// Once the entry has expired, it is removed from the map and Get returns a NotFound error. If the server
+// does not apply the TTL, e.g. because it does not support TTLs or because the put did not change the value
+// of an entry that has no TTL, Put returns a NotSupported error and the entry does not expire.
+func WithTTL(ttl time.Duration) PutOption {
+	return ttlOption{ttl: ttl}
+}
+
+type ttlOption struct {
+	ttl time.Duration
+}
+
+func (o ttlOption) beforePut(request *api.PutRequest) {
+	if request.Entry.Value == nil {
+		request.Entry.Value = &api.Value{}
+	}
+	request.Entry.Value.TTL = &o.ttl
+}
+
+func (o ttlOption) afterPut(response *api.PutResponse) {
+
+}
+
+// MergeOption is an option for the MergeJSON method
+type MergeOption interface {
+	applyMerge(options *mergeOptions)
+}
+
+// mergeOptions is a set of MergeJSON options
+type mergeOptions struct {
+	deep bool
+}
+
+// WithDeepMerge merges nested JSON objects recursively
+// By default, MergeJSON performs a shallow merge in which top-level values in the patch replace the
+// values in the map.
+func WithDeepMerge() MergeOption {
+	return deepMergeOption{}
+}
+
+type deepMergeOption struct{}
+
+func (o deepMergeOption) applyMerge(options *mergeOptions) {
+	options.deep = true
+}
+
+// ReconcileOption is an option for the ReconcileTo method
+type ReconcileOption interface {
+	applyReconcile(options *reconcileOptions)
+}
+
+// reconcileOptions is a set of ReconcileTo options
+type reconcileOptions struct {
+	dryRun      bool
+	parallelism int
+}
+
+// WithDryRun computes the changes required to reconcile the map without applying them
+// The planned changes are returned in the ReconcileResult.
+func WithDryRun() ReconcileOption {
+	return dryRunOption{}
+}
+
+type dryRunOption struct{}
+
+func (o dryRunOption) applyReconcile(options *reconcileOptions) {
+	options.dryRun = true
+}
+
+// WithParallelism sets the maximum number of changes to apply concurrently
+func WithParallelism(parallelism int) ReconcileOption {
+	return parallelismOption{parallelism: parallelism}
+}
+
+type parallelismOption struct {
+	parallelism int
+}
+
+func (o parallelismOption) applyReconcile(options *reconcileOptions) {
+	options.parallelism = o.parallelism
+}
+
 // GetOption is an option for the Get method
 type GetOption interface {
 	beforeGet(request *api.GetRequest)
 	afterGet(response *api.GetResponse)
 }
 
+// WithHedging returns a Get option that hedges the read against tail latency
+// If the read has not succeeded within the given delay, or fails before it, a second request is issued and the
+// result of whichever request succeeds first is used; the read fails only if both requests fail. Both requests
+// are sent on the primitive's connection, so hedging does not route around an unresponsive connection.
+func WithHedging(delay time.Duration) GetOption {
+	return hedgingOption{delay: delay}
+}
+
+type hedgingOption struct {
+	delay time.Duration
+}
+
+func (o hedgingOption) beforeGet(request *api.GetRequest) {}
+
+func (o hedgingOption) afterGet(response *api.GetResponse) {}
+
+// EntriesOption is an option for the Entries method
+type EntriesOption interface {
+	beforeEntries(request *api.EntriesRequest)
+	afterEntries(response *api.EntriesResponse)
+}
+
+// WithSortedKeys returns an entries option that delivers entries sorted by key
+// The server delivers entries in an unspecified order, so sorting requires all entries to be buffered in
+// memory until the stream is complete. No entries are delivered until the last entry has been received, and
+// if the stream fails, the buffered entries are discarded.
+func WithSortedKeys() EntriesOption {
+	return sortedKeysOption{}
+}
+
+type sortedKeysOption struct{}
+
+func (o sortedKeysOption) beforeEntries(request *api.EntriesRequest) {
+
+}
+
+func (o sortedKeysOption) afterEntries(response *api.EntriesResponse) {
+
+}
+
 // WatchOption is an option for the Watch method
 type WatchOption interface {
 	beforeWatch(request *api.EventsRequest)
@@ -120,6 +289,65 @@ func (o replayOption) afterWatch(response *api.EventsResponse) {
 
 }
 
+// WithSharedStream returns a watch option that shares a single upstream stream between watches
+// Watches with the option on the same map share one stream to the server, which is closed once the last
+// sharing watch's context is cancelled. Each watch queues its own events, so a watch that does not consume
+// its channel does not delay the others, but a watch whose queue fills up is closed; see WithWatchQueueSize.
+// The option has no effect on watches with options that change the stream, e.g. WithReplay or WithFilter,
+// which always open their own stream.
+func WithSharedStream() WatchOption {
+	return sharedStreamOption{}
+}
+
+type sharedStreamOption struct{}
+
+func (o sharedStreamOption) beforeWatch(request *api.EventsRequest) {
+
+}
+
+func (o sharedStreamOption) afterWatch(response *api.EventsResponse) {
+
+}
+
+// WithHistory returns a watch option that delivers the events retained by the map's shared watch stream
+// before live events
+// The option implies WithSharedStream. History is retained only if the map was created with the
+// WithWatchHistory option. The option has no effect on watches with options that change the stream, which
+// do not share the upstream stream.
+func WithHistory() WatchOption {
+	return historyOption{}
+}
+
+type historyOption struct{}
+
+func (o historyOption) beforeWatch(request *api.EventsRequest) {
+
+}
+
+func (o historyOption) afterWatch(response *api.EventsResponse) {
+
+}
+
+// WithPrevValues returns a watch option that delivers the previous value of each updated entry
+// The watch retains the last value it has seen for each key, populated from replayed and live events, and
+// sets the PrevValue of each update event from it. Because the watch holds a copy of the value of every key
+// it has seen, the memory used grows with the size of the watched entries; combine the option with WithFilter
+// to limit the watch to a single key. Use the option with WithReplay so the values of keys that are not
+// modified after the watch is opened are known.
+func WithPrevValues() WatchOption {
+	return prevValuesOption{}
+}
+
+type prevValuesOption struct{}
+
+func (o prevValuesOption) beforeWatch(request *api.EventsRequest) {
+
+}
+
+func (o prevValuesOption) afterWatch(response *api.EventsResponse) {
+
+}
+
 type filterOption struct {
 	filter Filter
 }
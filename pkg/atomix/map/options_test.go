@@ -9,6 +9,7 @@ import (
 	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestOptions(t *testing.T) {
@@ -25,3 +26,20 @@ func TestOptions(t *testing.T) {
 	WithReplay().beforeWatch(eventRequest)
 	assert.True(t, eventRequest.Replay)
 }
+
+func TestTTLOption(t *testing.T) {
+	putRequest := &api.PutRequest{}
+	WithTTL(time.Second).beforePut(putRequest)
+	assert.Equal(t, time.Second, *putRequest.Entry.Value.TTL)
+
+	putRequest = &api.PutRequest{
+		Entry: api.Entry{
+			Value: &api.Value{
+				Value: []byte("foo"),
+			},
+		},
+	}
+	WithTTL(time.Minute).beforePut(putRequest)
+	assert.Equal(t, time.Minute, *putRequest.Entry.Value.TTL)
+	assert.Equal(t, "foo", string(putRequest.Entry.Value.Value))
+}
@@ -0,0 +1,343 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package _map //nolint:golint
+
+import (
+	"context"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
+	"sync"
+)
+
+// defaultWatchQueueSize is the default maximum number of events queued for a shared watch subscriber
+const defaultWatchQueueSize = 1000
+
+// watchFunc opens an upstream watch stream
+type watchFunc func(ctx context.Context, ch chan<- Event, opts ...WatchOption) error
+
+// watchMux multiplexes local watch subscribers onto a single upstream watch stream
+// The upstream stream is opened by the first subscriber and closed once the last subscriber's
+// context is cancelled. If historySize is positive, the most recent events received on the stream are
+// retained for delivery to late subscribers. Each subscriber queues at most queueSize events, or
+// defaultWatchQueueSize if queueSize is not positive. The zero value is ready to use.
+type watchMux struct {
+	historySize int
+	queueSize   int
+	stream      *sharedWatch
+	mu          sync.Mutex
+}
+
+// sharedWatch is an upstream watch stream shared by a set of subscribers
+// The ready channel is closed once the attempt to open the stream has completed, after which err holds
+// the error with which opening the stream failed, if any. A closed stream accepts no new subscribers.
+type sharedWatch struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	ready       chan struct{}
+	err         error
+	closed      bool
+	subscribers map[*watchSubscriber]bool
+	history     *eventRing
+}
+
+// newEventRing creates a new ring buffer retaining the given number of events
+func newEventRing(size int) *eventRing {
+	return &eventRing{
+		events: make([]Event, size),
+	}
+}
+
+// eventRing is a fixed size ring buffer of events
+type eventRing struct {
+	events []Event
+	start  int
+	count  int
+}
+
+// add adds an event to the buffer, evicting the oldest event if the buffer is full
+func (r *eventRing) add(event Event) {
+	if len(r.events) == 0 {
+		return
+	}
+	if r.count < len(r.events) {
+		r.events[(r.start+r.count)%len(r.events)] = event
+		r.count++
+	} else {
+		r.events[r.start] = event
+		r.start = (r.start + 1) % len(r.events)
+	}
+}
+
+// list returns the buffered events from oldest to newest
+func (r *eventRing) list() []Event {
+	events := make([]Event, r.count)
+	for i := 0; i < r.count; i++ {
+		events[i] = r.events[(r.start+i)%len(r.events)]
+	}
+	return events
+}
+
+// newWatchSubscriber creates a new subscriber delivering the given pending events before live events
+// The subscriber's events are delivered by its own goroutine, which closes the subscriber's channel once the
+// subscriber's context is cancelled or, after all queued events have been delivered, the subscriber is closed.
+// The queue size is raised to the number of pending events so the pending events alone never fill the queue.
+func newWatchSubscriber(ctx context.Context, ch chan<- Event, pending []Event, queueSize int) *watchSubscriber {
+	if len(pending) > queueSize {
+		queueSize = len(pending)
+	}
+	subscriber := &watchSubscriber{
+		ctx:       ctx,
+		ch:        ch,
+		doneCh:    make(chan struct{}),
+		notify:    make(chan struct{}, 1),
+		queue:     pending,
+		queueSize: queueSize,
+	}
+	go subscriber.run()
+	return subscriber
+}
+
+// watchSubscriber is a local subscriber to a shared watch stream
+// Each subscriber queues its events, so a subscriber that does not consume its channel does not delay
+// delivery to the other subscribers of the stream.
+type watchSubscriber struct {
+	ctx       context.Context
+	ch        chan<- Event
+	doneCh    chan struct{}
+	notify    chan struct{}
+	queue     []Event
+	queueSize int
+	closed    bool
+	mu        sync.Mutex
+}
+
+// send queues the given event for delivery to the subscriber
+// If the subscriber's queue is full, the event is not queued and false is returned.
+func (s *watchSubscriber) send(event Event) bool {
+	s.mu.Lock()
+	if !s.closed {
+		if len(s.queue) >= s.queueSize {
+			s.mu.Unlock()
+			return false
+		}
+		s.queue = append(s.queue, event)
+	}
+	s.mu.Unlock()
+	s.signal()
+	return true
+}
+
+// close closes the subscriber once its queued events have been delivered
+func (s *watchSubscriber) close() {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.doneCh)
+	}
+	s.mu.Unlock()
+	s.signal()
+}
+
+func (s *watchSubscriber) signal() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run delivers queued events to the subscriber's channel until the subscriber is closed or its context is done
+func (s *watchSubscriber) run() {
+	defer close(s.ch)
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return
+			}
+			select {
+			case <-s.notify:
+			case <-s.ctx.Done():
+				return
+			}
+			continue
+		}
+		event := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+
+		select {
+		case s.ch <- event:
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// subscribe adds a subscriber to the shared stream, opening the stream with the given function if necessary
+// If history is true, the events retained by the stream are delivered to the subscriber before live events.
+// The stream is opened without holding the mux's lock; concurrent subscribers wait for the attempt to open
+// the stream to complete and share its result.
+func (x *watchMux) subscribe(ctx context.Context, ch chan<- Event, open watchFunc, history bool) error {
+	for {
+		x.mu.Lock()
+		stream := x.stream
+		opening := stream == nil
+		if opening {
+			stream = x.newStream()
+		}
+		x.mu.Unlock()
+
+		if opening {
+			x.open(ctx, stream, open)
+		}
+		select {
+		case <-stream.ready:
+		case <-ctx.Done():
+			return errors.From(ctx.Err())
+		}
+
+		if stream.err != nil {
+			// If opening the stream was aborted by the cancellation of another subscriber's context,
+			// a new attempt is made to open the stream
+			if !opening && errors.IsCanceled(stream.err) {
+				continue
+			}
+			return stream.err
+		}
+
+		x.mu.Lock()
+		if stream.closed {
+			x.mu.Unlock()
+			continue
+		}
+		var pending []Event
+		if history && stream.history != nil {
+			// Events added to the history after this point are delivered to the subscriber as live events
+			pending = stream.history.list()
+		}
+		subscriber := newWatchSubscriber(ctx, ch, pending, x.getQueueSize())
+		stream.subscribers[subscriber] = true
+		x.mu.Unlock()
+
+		go func() {
+			select {
+			case <-ctx.Done():
+				x.unsubscribe(stream, subscriber)
+			case <-subscriber.doneCh:
+			}
+		}()
+		return nil
+	}
+}
+
+// getQueueSize returns the maximum number of events queued for each subscriber
+func (x *watchMux) getQueueSize() int {
+	if x.queueSize > 0 {
+		return x.queueSize
+	}
+	return defaultWatchQueueSize
+}
+
+// newStream creates a new shared stream and sets it as the mux's current stream
+// The caller must hold the mux's lock and open the stream.
+func (x *watchMux) newStream() *sharedWatch {
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &sharedWatch{
+		ctx:         ctx,
+		cancel:      cancel,
+		ready:       make(chan struct{}),
+		subscribers: make(map[*watchSubscriber]bool),
+	}
+	if x.historySize > 0 {
+		stream.history = newEventRing(x.historySize)
+	}
+	x.stream = stream
+	return stream
+}
+
+// open opens the given shared stream on behalf of the subscriber with the given context
+// The upstream stream outlives the subscriber that opens it, but opening the stream is aborted if that
+// subscriber's context is cancelled first.
+func (x *watchMux) open(ctx context.Context, stream *sharedWatch, open watchFunc) {
+	opened := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			stream.cancel()
+		case <-opened:
+		}
+	}()
+
+	events := make(chan Event)
+	err := open(stream.ctx, events)
+	close(opened)
+	if err != nil {
+		stream.cancel()
+		x.mu.Lock()
+		stream.err = err
+		stream.closed = true
+		if x.stream == stream {
+			x.stream = nil
+		}
+		x.mu.Unlock()
+		close(stream.ready)
+		return
+	}
+	close(stream.ready)
+	go x.fanOut(stream, events)
+}
+
+// unsubscribe removes a subscriber from the given stream, closing the stream if no subscribers remain
+func (x *watchMux) unsubscribe(stream *sharedWatch, subscriber *watchSubscriber) {
+	x.mu.Lock()
+	if stream.subscribers[subscriber] {
+		delete(stream.subscribers, subscriber)
+		if len(stream.subscribers) == 0 && x.stream == stream {
+			x.stream = nil
+			stream.closed = true
+			stream.cancel()
+		}
+	}
+	x.mu.Unlock()
+	subscriber.close()
+}
+
+// fanOut queues events from the given upstream stream for delivery to its subscribers
+// A subscriber whose queue is full is removed from the stream, and its channel is closed once the events
+// already queued have been delivered.
+func (x *watchMux) fanOut(stream *sharedWatch, events <-chan Event) {
+	for event := range events {
+		x.mu.Lock()
+		if stream.history != nil {
+			stream.history.add(event)
+		}
+		subscribers := make([]*watchSubscriber, 0, len(stream.subscribers))
+		for subscriber := range stream.subscribers {
+			subscribers = append(subscribers, subscriber)
+		}
+		x.mu.Unlock()
+		for _, subscriber := range subscribers {
+			if !subscriber.send(event) {
+				log.Warnf("Closing shared watch subscriber with %d unconsumed events", x.getQueueSize())
+				x.unsubscribe(stream, subscriber)
+			}
+		}
+	}
+
+	// The upstream stream has been closed, so close the remaining subscribers' channels
+	x.mu.Lock()
+	if x.stream == stream {
+		x.stream = nil
+	}
+	stream.closed = true
+	subscribers := stream.subscribers
+	stream.subscribers = make(map[*watchSubscriber]bool)
+	x.mu.Unlock()
+	stream.cancel()
+	for subscriber := range subscribers {
+		subscriber.close()
+	}
+}
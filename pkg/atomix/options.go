@@ -4,6 +4,14 @@
 
 package atomix
 
+import (
+	"context"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"google.golang.org/grpc/keepalive"
+	"net"
+	"time"
+)
+
 // Option is a client option
 type Option interface {
 	apply(*clientOptions)
@@ -16,9 +24,24 @@ func (EmptyOption) apply(*clientOptions) {}
 
 // clientOptions is a set of client options
 type clientOptions struct {
-	clientID   string
-	brokerHost string
-	brokerPort int
+	clientID        string
+	brokerHost      string
+	brokerPort      int
+	keepalive       *keepalive.ClientParameters
+	maxRecvMsgSize  int
+	maxSendMsgSize  int
+	retryBudget     *retryBudgetOptions
+	tenant          string
+	dialer          func(context.Context, string) (net.Conn, error)
+	maxInFlight     int
+	shutdownTimeout time.Duration
+	slowThreshold   time.Duration
+}
+
+// retryBudgetOptions is the configuration of a client retry budget
+type retryBudgetOptions struct {
+	tokens   int
+	interval time.Duration
 }
 
 // WithClientID sets the client identifier
@@ -68,3 +91,178 @@ type portOption struct {
 func (o *portOption) apply(options *clientOptions) {
 	options.brokerPort = o.port
 }
+
+// WithKeepalive configures gRPC keepalive pings for primitive connections
+// Keepalive pings prevent idle connections, e.g. connections used only by long-lived watch streams, from being
+// dropped by intermediaries. See keepalive.ClientParameters for the semantics of each parameter.
+func WithKeepalive(params keepalive.ClientParameters) Option {
+	return &keepaliveOption{
+		params: params,
+	}
+}
+
+// keepaliveOption is a keepalive option
+type keepaliveOption struct {
+	params keepalive.ClientParameters
+}
+
+func (o *keepaliveOption) apply(options *clientOptions) {
+	params := o.params
+	options.keepalive = &params
+}
+
+// WithMaxRecvMsgSize sets the maximum size in bytes of messages received from primitives
+// The maximum size must be large enough to receive the largest entries read from or streamed by primitives.
+func WithMaxRecvMsgSize(size int) Option {
+	return &maxRecvMsgSizeOption{
+		size: size,
+	}
+}
+
+// maxRecvMsgSizeOption is a maximum received message size option
+type maxRecvMsgSizeOption struct {
+	size int
+}
+
+func (o *maxRecvMsgSizeOption) apply(options *clientOptions) {
+	options.maxRecvMsgSize = o.size
+}
+
+// WithMaxSendMsgSize sets the maximum size in bytes of messages sent to primitives
+func WithMaxSendMsgSize(size int) Option {
+	return &maxSendMsgSizeOption{
+		size: size,
+	}
+}
+
+// maxSendMsgSizeOption is a maximum sent message size option
+type maxSendMsgSizeOption struct {
+	size int
+}
+
+func (o *maxSendMsgSizeOption) apply(options *clientOptions) {
+	options.maxSendMsgSize = o.size
+}
+
+// WithRetryBudget limits the rate at which failed requests are retried across all primitives
+// Retries draw from a shared token bucket holding at most the given number of tokens, with one token added
+// per interval. The first attempt of each request does not consume a token. Once the budget is exhausted,
+// failed requests are not retried and the error returned by the last attempt is returned. Only primitives
+// created through the client draw from the budget; primitives created with a package constructor, e.g.
+// counter.New, on a connection dialed by the caller are not limited.
+func WithRetryBudget(tokens int, interval time.Duration) Option {
+	return &retryBudgetOption{
+		tokens:   tokens,
+		interval: interval,
+	}
+}
+
+// retryBudgetOption is a retry budget option
+type retryBudgetOption struct {
+	tokens   int
+	interval time.Duration
+}
+
+func (o *retryBudgetOption) apply(options *clientOptions) {
+	options.retryBudget = &retryBudgetOptions{
+		tokens:   o.tokens,
+		interval: o.interval,
+	}
+}
+
+// WithTenant sets the tenant on whose behalf requests are made
+// The tenant identifier is attached to the outgoing metadata of every request, including requests to the
+// broker, under TenantKey, allowing a cluster to be shared by isolated tenants. As a client option, the tenant
+// applies to all primitives created by the client. As a primitive option, it overrides the client's tenant for
+// that primitive, including the broker lookup of the primitive.
+func WithTenant(id string) TenantOption {
+	return TenantOption{
+		tenant: id,
+	}
+}
+
+// TenantOption is a client and primitive option that sets the tenant identifier
+type TenantOption struct {
+	primitive.EmptyOption
+	tenant string
+}
+
+func (o TenantOption) apply(options *clientOptions) {
+	options.tenant = o.tenant
+}
+
+// WithContextDialer sets the function used to connect to the broker and to primitives
+// The dialer is called with the address of the broker or primitive, and can be used to connect over
+// transports other than TCP, e.g. unix domain sockets or in-process listeners.
+func WithContextDialer(dialer func(ctx context.Context, addr string) (net.Conn, error)) Option {
+	return &dialerOption{
+		dialer: dialer,
+	}
+}
+
+// dialerOption is a dialer option
+type dialerOption struct {
+	dialer func(context.Context, string) (net.Conn, error)
+}
+
+func (o *dialerOption) apply(options *clientOptions) {
+	options.dialer = o.dialer
+}
+
+// WithMaxInFlight limits the number of concurrent outstanding requests made by each session of the client
+// Requests in excess of the limit are queued until an outstanding request completes or the request's
+// context is done. The limit applies separately to each session: primitives created by the client share the
+// client's session, and so its limit, unless a session identifier is set for the primitive. Streams count
+// against the limit only while they are being opened. Only primitives created through the client are limited;
+// primitives created with a package constructor, e.g. counter.New, on a connection dialed by the caller are not.
+func WithMaxInFlight(n int) Option {
+	return &maxInFlightOption{
+		n: n,
+	}
+}
+
+// maxInFlightOption is a maximum in-flight requests option
+type maxInFlightOption struct {
+	n int
+}
+
+func (o *maxInFlightOption) apply(options *clientOptions) {
+	options.maxInFlight = o.n
+}
+
+// WithShutdownTimeout sets the maximum time Close waits for the client's streams to drain
+// When the client is closed, primitive sessions are closed first, and the client then waits up to the given
+// timeout for open streams, e.g. watches, to end before closing its connections.
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return &shutdownTimeoutOption{
+		timeout: timeout,
+	}
+}
+
+// shutdownTimeoutOption is a shutdown timeout option
+type shutdownTimeoutOption struct {
+	timeout time.Duration
+}
+
+func (o *shutdownTimeoutOption) apply(options *clientOptions) {
+	options.shutdownTimeout = o.timeout
+}
+
+// WithSlowThreshold logs a warning for each primitive operation that takes longer than the given threshold
+// The warning includes the primitive type and name, the operation, and the elapsed time. Operations are timed
+// from the time they are issued until the response is received, including any retries and time spent waiting
+// for an in-flight slot. Streams, e.g. watches, are timed only while the stream is being opened.
+func WithSlowThreshold(threshold time.Duration) Option {
+	return &slowThresholdOption{
+		threshold: threshold,
+	}
+}
+
+// slowThresholdOption is a slow operation threshold option
+type slowThresholdOption struct {
+	threshold time.Duration
+}
+
+func (o *slowThresholdOption) apply(options *clientOptions) {
+	options.slowThreshold = o.threshold
+}
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package atomix
+
+import (
+	"context"
+	brokerapi "github.com/atomix/atomix-api/go/atomix/management/broker"
+	_map "github.com/atomix/atomix-go-client/pkg/atomix/map"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKeepaliveOption(t *testing.T) {
+	client := NewClient().(*atomixClient)
+	assert.Nil(t, client.options.keepalive)
+	brokerOpts := client.getBrokerDialOptions()
+	primitiveOpts := client.getPrimitiveDialOptions()
+
+	params := keepalive.ClientParameters{
+		Time:                10 * time.Second,
+		Timeout:             time.Second,
+		PermitWithoutStream: true,
+	}
+	client = NewClient(WithKeepalive(params)).(*atomixClient)
+	assert.NotNil(t, client.options.keepalive)
+	assert.Equal(t, params, *client.options.keepalive)
+	assert.Len(t, client.getBrokerDialOptions(), len(brokerOpts)+1)
+	assert.Len(t, client.getPrimitiveDialOptions(), len(primitiveOpts)+1)
+}
+
+func TestContextDialerOption(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	brokerapi.RegisterBrokerServer(server, &testBroker{
+		primitives: map[brokerapi.PrimitiveId]bool{
+			{PrimitiveId: newPrimitiveID(_map.Type, "foo")}: true,
+		},
+	})
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	defer server.Stop()
+
+	// All connections are made over the in-process listener
+	var addrs []string
+	var mu sync.Mutex
+	dialer := func(ctx context.Context, addr string) (net.Conn, error) {
+		mu.Lock()
+		addrs = append(addrs, addr)
+		mu.Unlock()
+		return lis.Dial()
+	}
+	client := NewClient(WithBrokerHost("broker"), WithBrokerPort(1234), WithContextDialer(dialer)).(*atomixClient)
+	defer client.Close()
+
+	exists, err := client.Exists(context.Background(), _map.Type, "foo")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	conn, err := client.connect(context.Background(), newPrimitiveID(_map.Type, "foo"))
+	assert.NoError(t, err)
+	assert.NoError(t, client.Warmup(context.Background()))
+	assert.Equal(t, connectivity.Ready, conn.GetState())
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Contains(t, addrs, "broker:1234")
+	assert.Contains(t, addrs, "127.0.0.1:5679")
+}
@@ -0,0 +1,32 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package atomix
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// preflightInterceptors returns interceptors that fail requests whose context is already done
+// The check runs before any other interceptor, so a request made with an expired or cancelled context does not
+// wait for an in-flight slot, draw from the retry budget, or reach the server. The request fails with the same
+// status the gRPC runtime reports for a context that is done during a request. The interceptors are installed
+// only on the connections dialed by the client, so primitives created on other connections are not checked.
+func preflightInterceptors() (grpc.UnaryClientInterceptor, grpc.StreamClientInterceptor) {
+	unary := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	stream := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, status.FromContextError(err).Err()
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+	return unary, stream
+}
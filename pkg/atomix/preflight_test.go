@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package atomix
+
+import (
+	"context"
+	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	mapapi "github.com/atomix/atomix-api/go/atomix/primitive/map"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
+	_map "github.com/atomix/atomix-go-client/pkg/atomix/map"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testCountingMapServer is a map server that counts the requests it receives
+type testCountingMapServer struct {
+	mapapi.UnimplementedMapServiceServer
+	requests int
+	mu       sync.Mutex
+}
+
+func (s *testCountingMapServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *testCountingMapServer) Get(ctx context.Context, request *mapapi.GetRequest) (*mapapi.GetResponse, error) {
+	s.mu.Lock()
+	s.requests++
+	s.mu.Unlock()
+	return &mapapi.GetResponse{
+		Entry: mapapi.Entry{
+			Key: mapapi.Key{
+				Key: request.Key,
+			},
+			Value: &mapapi.Value{},
+		},
+	}, nil
+}
+
+func (s *testCountingMapServer) Events(request *mapapi.EventsRequest, stream mapapi.MapService_EventsServer) error {
+	s.mu.Lock()
+	s.requests++
+	s.mu.Unlock()
+	return nil
+}
+
+func TestPreflight(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := grpc.NewServer()
+	mapServer := &testCountingMapServer{}
+	primitiveapi.RegisterPrimitiveServer(server, &testPrimitiveServer{})
+	mapapi.RegisterMapServiceServer(server, mapServer)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	defer server.Stop()
+
+	client := NewClient(WithMaxInFlight(1), WithRetryBudget(1, time.Second)).(*atomixClient)
+	conn, err := grpc.Dial(lis.Addr().String(), client.getPrimitiveDialOptions()...)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	m, err := _map.New(context.Background(), "TestPreflight", conn)
+	assert.NoError(t, err)
+	_, err = m.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, mapServer.count())
+
+	// A cancelled context fails without a request being sent
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = m.Get(ctx, "foo")
+	assert.True(t, errors.IsCanceled(err))
+	err = m.Watch(ctx, make(chan _map.Event), _map.WithReplay())
+	assert.True(t, errors.IsCanceled(err))
+	assert.Equal(t, 1, mapServer.count())
+
+	// An expired context fails without a request being sent
+	ctx, cancel = context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	_, err = m.Get(ctx, "foo")
+	assert.True(t, errors.IsTimeout(err))
+	assert.Equal(t, 1, mapServer.count())
+}
@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package primitive
+
+import (
+	"context"
+	"sync"
+)
+
+// CloseOnContext closes the given primitive once the given context is done
+// The returned function detaches the primitive from the context; once detached, the primitive will not be
+// closed when the context is done. If the primitive is being closed when it's detached, the detach function
+// waits for the primitive to be closed.
+func CloseOnContext(ctx context.Context, primitive Primitive) (detach func()) {
+	detachCh := make(chan struct{})
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		select {
+		case <-ctx.Done():
+			if err := primitive.Close(context.Background()); err != nil {
+				log.Warnf("Failed to close %s %s: %v", primitive.Type(), primitive.Name(), err)
+			}
+		case <-detachCh:
+		}
+	}()
+	once := &sync.Once{}
+	return func() {
+		once.Do(func() {
+			close(detachCh)
+		})
+		<-doneCh
+	}
+}
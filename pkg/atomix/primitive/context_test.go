@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package primitive
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type testPrimitive struct {
+	closeCh chan struct{}
+}
+
+func (p *testPrimitive) Type() Type {
+	return "Test"
+}
+
+func (p *testPrimitive) Name() string {
+	return "test"
+}
+
+func (p *testPrimitive) Close(ctx context.Context) error {
+	close(p.closeCh)
+	return nil
+}
+
+func TestCloseOnContext(t *testing.T) {
+	primitive := &testPrimitive{closeCh: make(chan struct{})}
+	ctx, cancel := context.WithCancel(context.Background())
+	detach := CloseOnContext(ctx, primitive)
+	cancel()
+
+	select {
+	case <-primitive.closeCh:
+	case <-time.After(time.Second):
+		t.Fatal("primitive was not closed")
+	}
+	detach()
+}
+
+func TestCloseOnContextDetached(t *testing.T) {
+	primitive := &testPrimitive{closeCh: make(chan struct{})}
+	ctx, cancel := context.WithCancel(context.Background())
+	detach := CloseOnContext(ctx, primitive)
+	detach()
+	detach()
+	cancel()
+
+	select {
+	case <-primitive.closeCh:
+		t.Fatal("detached primitive was closed")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
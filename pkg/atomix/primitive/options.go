@@ -4,6 +4,10 @@
 
 package primitive
 
+import (
+	"time"
+)
+
 // Option is a primitive option
 type Option interface {
 	applyNew(*newOptions)
@@ -16,8 +20,13 @@ func (EmptyOption) applyNew(*newOptions) {}
 
 // newOptions is a set of primitive options
 type newOptions struct {
-	clusterKey string
-	sessionID  string
+	clusterKey     string
+	sessionID      string
+	createAttempts int
+	createBackoff  time.Duration
+	// propagatePanics indicates panics raised by user callbacks are not recovered
+	propagatePanics bool
+	closeHooks      []func()
 }
 
 // WithClusterKey sets the primitive cluster key
@@ -51,3 +60,71 @@ type sessionIDOption struct {
 func (o *sessionIDOption) applyNew(options *newOptions) {
 	options.sessionID = o.sessionID
 }
+
+// GetSessionID returns the session identifier set by the given options
+// If no option sets the session identifier, an empty string is returned.
+func GetSessionID(opts ...Option) string {
+	options := newOptions{}
+	for _, opt := range opts {
+		opt.applyNew(&options)
+	}
+	return options.sessionID
+}
+
+// WithCreateRetry retries failed attempts to create the primitive while the service is unavailable
+// Up to the given number of attempts are made, with the delay between attempts starting at the given backoff
+// and doubling after each attempt. Retries are abandoned once the creation context is done.
+func WithCreateRetry(attempts int, backoff time.Duration) Option {
+	return &createRetryOption{
+		attempts: attempts,
+		backoff:  backoff,
+	}
+}
+
+// createRetryOption is a creation retry option
+type createRetryOption struct {
+	attempts int
+	backoff  time.Duration
+}
+
+func (o *createRetryOption) applyNew(options *newOptions) {
+	options.createAttempts = o.attempts
+	options.createBackoff = o.backoff
+}
+
+// WithPanicRecovery sets whether panics raised by user callbacks are recovered
+// Panic recovery is enabled by default: a callback that panics, e.g. a compute function or a watch listener,
+// fails the helper that invoked it with an error matching errors.ErrPanic rather than crashing the
+// program. Disabling panic recovery propagates the panic to the invoking goroutine.
+func WithPanicRecovery(enabled bool) Option {
+	return &panicRecoveryOption{
+		enabled: enabled,
+	}
+}
+
+// panicRecoveryOption is a panic recovery option
+type panicRecoveryOption struct {
+	enabled bool
+}
+
+func (o *panicRecoveryOption) applyNew(options *newOptions) {
+	options.propagatePanics = !o.enabled
+}
+
+// WithCloseHook adds a function to be called once the primitive has been closed
+// The hook is called by Close after the primitive's session has been closed successfully, and is not called
+// if closing the primitive fails.
+func WithCloseHook(hook func()) Option {
+	return &closeHookOption{
+		hook: hook,
+	}
+}
+
+// closeHookOption is a close hook option
+type closeHookOption struct {
+	hook func()
+}
+
+func (o *closeHookOption) applyNew(options *newOptions) {
+	options.closeHooks = append(options.closeHooks, o.hook)
+}
@@ -7,10 +7,18 @@ package primitive
 import (
 	"context"
 	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
-	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	metaapi "github.com/atomix/atomix-api/go/atomix/primitive/meta"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
+	metatime "github.com/atomix/atomix-go-framework/pkg/atomix/time"
 	"google.golang.org/grpc"
+	"runtime/debug"
+	"sync"
+	"time"
 )
 
+var log = logging.GetLogger("atomix", "client", "primitive")
+
 // Type is the type of a primitive
 type Type string
 
@@ -30,6 +38,14 @@ type Primitive interface {
 	Close(ctx context.Context) error
 }
 
+// TypeOf returns the type of the given primitive, or an empty Type if the primitive is nil
+func TypeOf(p Primitive) Type {
+	if p == nil {
+		return ""
+	}
+	return p.Type()
+}
+
 // NewClient creates a new primitive client
 func NewClient(primitiveType Type, name string, conn *grpc.ClientConn, opts ...Option) *Client {
 	options := newOptions{}
@@ -50,6 +66,8 @@ type Client struct {
 	name          string
 	client        primitiveapi.PrimitiveClient
 	options       newOptions
+	closeMu       sync.Mutex
+	closed        bool
 }
 
 // Type returns the primitive type
@@ -62,6 +80,21 @@ func (c *Client) SessionID() string {
 	return c.options.sessionID
 }
 
+// InvokeCallback invokes the given user callback, converting a panic raised by the callback to an error
+// If panic recovery has not been disabled with WithPanicRecovery, a panic is recovered and returned as
+// an error matching errors.ErrPanic.
+func (c *Client) InvokeCallback(f func() error) (err error) {
+	if c.options.propagatePanics {
+		return f()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.NewPanic(r, debug.Stack())
+		}
+	}()
+	return f()
+}
+
 // Name returns the primitive name
 func (c *Client) Name() string {
 	return c.name
@@ -82,8 +115,54 @@ func (c *Client) GetHeaders() primitiveapi.RequestHeaders {
 	}
 }
 
+// GetTimestamp gets the timestamp from the given response headers
+// Primitives use GetTimestamp to set the Timestamp of the events delivered by watches, which is the time at
+// which the event occurred as reported by the server. The scheme of the timestamp (e.g. logical or physical)
+// is determined by the server. If the headers do not carry a timestamp in a supported scheme, nil is returned,
+// so event timestamps are nil if the server does not report them.
+func GetTimestamp(headers primitiveapi.ResponseHeaders) metatime.Timestamp {
+	if headers.Timestamp == nil {
+		return nil
+	}
+	switch headers.Timestamp.Timestamp.(type) {
+	case *metaapi.Timestamp_PhysicalTimestamp,
+		*metaapi.Timestamp_LogicalTimestamp,
+		*metaapi.Timestamp_EpochTimestamp,
+		*metaapi.Timestamp_CompositeTimestamp:
+		return metatime.NewTimestamp(*headers.Timestamp)
+	}
+	return nil
+}
+
 // Create creates an instance of the primitive
+// If the WithCreateRetry option was provided, creation is retried while the service is unavailable.
 func (c *Client) Create(ctx context.Context) error {
+	request := &primitiveapi.CreateRequest{
+		Headers: c.GetHeaders(),
+	}
+	backoff := c.options.createBackoff
+	for attempt := 1; ; attempt++ {
+		_, err := c.client.Create(ctx, request)
+		if err == nil {
+			return nil
+		}
+		err = errors.From(err)
+		if !errors.IsUnavailable(err) || attempt >= c.options.createAttempts {
+			return err
+		}
+		log.Warnf("Create attempt %d for %s failed: %v", attempt, c.name, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return errors.From(ctx.Err())
+		}
+		backoff *= 2
+	}
+}
+
+// Ping checks that the primitive's service is reachable
+// The primitive is idempotently re-created, so a successful Ping also ensures the primitive exists.
+func (c *Client) Ping(ctx context.Context) error {
 	request := &primitiveapi.CreateRequest{
 		Headers: c.GetHeaders(),
 	}
@@ -92,10 +171,22 @@ func (c *Client) Create(ctx context.Context) error {
 }
 
 // Close closes the primitive session
+// Closing a primitive that has already been closed has no effect.
 func (c *Client) Close(ctx context.Context) error {
+	c.closeMu.Lock()
+	defer c.closeMu.Unlock()
+	if c.closed {
+		return nil
+	}
 	request := &primitiveapi.CloseRequest{
 		Headers: c.GetHeaders(),
 	}
-	_, err := c.client.Close(ctx, request)
-	return errors.From(err)
+	if _, err := c.client.Close(ctx, request); err != nil {
+		return errors.From(err)
+	}
+	c.closed = true
+	for _, hook := range c.options.closeHooks {
+		hook()
+	}
+	return nil
 }
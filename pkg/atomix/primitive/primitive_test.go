@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package primitive
+
+import (
+	"context"
+	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+// testPrimitiveClient is a primitive client that fails creation with the given errors before succeeding
+type testPrimitiveClient struct {
+	primitiveapi.PrimitiveClient
+	failures []error
+	attempts int
+	closes   int
+}
+
+func (c *testPrimitiveClient) Close(ctx context.Context, request *primitiveapi.CloseRequest, opts ...grpc.CallOption) (*primitiveapi.CloseResponse, error) {
+	c.closes++
+	if len(c.failures) > 0 {
+		err := c.failures[0]
+		c.failures = c.failures[1:]
+		return nil, err
+	}
+	return &primitiveapi.CloseResponse{}, nil
+}
+
+func (c *testPrimitiveClient) Create(ctx context.Context, request *primitiveapi.CreateRequest, opts ...grpc.CallOption) (*primitiveapi.CreateResponse, error) {
+	c.attempts++
+	if len(c.failures) > 0 {
+		err := c.failures[0]
+		c.failures = c.failures[1:]
+		return nil, err
+	}
+	return &primitiveapi.CreateResponse{}, nil
+}
+
+func newTestClient(client primitiveapi.PrimitiveClient, opts ...Option) *Client {
+	c := NewClient("Test", "test", nil, opts...)
+	c.client = client
+	return c
+}
+
+func TestCreateRetry(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+
+	// Creation is not retried by default
+	client := &testPrimitiveClient{failures: []error{unavailable}}
+	err := newTestClient(client).Create(context.Background())
+	assert.True(t, errors.IsUnavailable(err))
+	assert.Equal(t, 1, client.attempts)
+
+	// Creation is retried while the service is unavailable
+	client = &testPrimitiveClient{failures: []error{unavailable, unavailable}}
+	err = newTestClient(client, WithCreateRetry(3, time.Millisecond)).Create(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, client.attempts)
+
+	// Creation fails once the attempts are exhausted
+	client = &testPrimitiveClient{failures: []error{unavailable, unavailable, unavailable}}
+	err = newTestClient(client, WithCreateRetry(2, time.Millisecond)).Create(context.Background())
+	assert.True(t, errors.IsUnavailable(err))
+	assert.Equal(t, 2, client.attempts)
+
+	// Other errors are not retried
+	client = &testPrimitiveClient{failures: []error{status.Error(codes.InvalidArgument, "invalid")}}
+	err = newTestClient(client, WithCreateRetry(3, time.Millisecond)).Create(context.Background())
+	assert.True(t, errors.IsInvalid(err))
+	assert.Equal(t, 1, client.attempts)
+}
+
+func TestCreateRetryContext(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	client := &testPrimitiveClient{failures: []error{unavailable, unavailable, unavailable}}
+
+	// Retries are abandoned once the context deadline is exceeded
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := newTestClient(client, WithCreateRetry(3, time.Second)).Create(ctx)
+	assert.True(t, errors.IsTimeout(err))
+	assert.Equal(t, 1, client.attempts)
+}
+
+func TestPing(t *testing.T) {
+	client := &testPrimitiveClient{failures: []error{status.Error(codes.Unavailable, "unavailable")}}
+	primitive := newTestClient(client, WithCreateRetry(3, time.Millisecond))
+
+	// Pings are not retried
+	err := primitive.Ping(context.Background())
+	assert.True(t, errors.IsUnavailable(err))
+	assert.Equal(t, 1, client.attempts)
+
+	err = primitive.Ping(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, client.attempts)
+}
+
+func TestInvokeCallback(t *testing.T) {
+	// Panics are recovered by default
+	client := newTestClient(&testPrimitiveClient{})
+	err := client.InvokeCallback(func() error {
+		panic("foo")
+	})
+	assert.True(t, errors.IsPanic(err))
+	var panicErr *errors.PanicError
+	assert.True(t, errors.As(err, &panicErr))
+	assert.Equal(t, "foo", panicErr.Value)
+	assert.NotEmpty(t, panicErr.Stack)
+
+	// Errors returned by the callback are returned unchanged
+	err = client.InvokeCallback(func() error {
+		return errors.NewInvalid("foo")
+	})
+	assert.True(t, errors.IsInvalid(err))
+	assert.False(t, errors.IsPanic(err))
+
+	// Panics are propagated if recovery is disabled
+	client = newTestClient(&testPrimitiveClient{}, WithPanicRecovery(false))
+	assert.PanicsWithValue(t, "foo", func() {
+		_ = client.InvokeCallback(func() error {
+			panic("foo")
+		})
+	})
+}
+
+func TestClose(t *testing.T) {
+	client := &testPrimitiveClient{failures: []error{status.Error(codes.Unavailable, "unavailable")}}
+	primitive := newTestClient(client)
+
+	// A failed close can be retried
+	err := primitive.Close(context.Background())
+	assert.True(t, errors.IsUnavailable(err))
+	assert.NoError(t, primitive.Close(context.Background()))
+	assert.Equal(t, 2, client.closes)
+
+	// Closing a closed primitive has no effect
+	assert.NoError(t, primitive.Close(context.Background()))
+	assert.Equal(t, 2, client.closes)
+}
@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package primitive
+
+import (
+	"context"
+	"sync"
+)
+
+// NewHandshake creates a new watch stream handshake
+func NewHandshake() *Handshake {
+	return &Handshake{
+		doneCh: make(chan struct{}),
+	}
+}
+
+// Handshake tracks the opening of a watch stream
+// Watch streams begin with an empty open marker event which completes the handshake. The marker is
+// not a data event and must not be delivered to watch consumers.
+type Handshake struct {
+	doneCh chan struct{}
+	done   bool
+	err    error
+	mu     sync.Mutex
+}
+
+// Open completes the handshake
+// Only the first call to Open or Fail has any effect. Open returns whether the handshake is open, i.e.
+// false if the handshake had already failed.
+func (h *Handshake) Open() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.done {
+		h.done = true
+		close(h.doneCh)
+	}
+	return h.err == nil
+}
+
+// Fail fails the handshake with the given error
+// Only the first call to Open or Fail has any effect. Fail returns whether the handshake has failed, i.e.
+// false if the handshake had already been opened.
+func (h *Handshake) Fail(err error) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.done {
+		h.done = true
+		h.err = err
+		close(h.doneCh)
+	}
+	return h.err != nil
+}
+
+// Wait waits for the handshake to complete or the given context to be done
+// If the handshake failed, the error with which it failed is returned.
+func (h *Handshake) Wait(ctx context.Context) error {
+	select {
+	case <-h.doneCh:
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		return h.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
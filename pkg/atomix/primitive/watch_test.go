@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package primitive
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestHandshake(t *testing.T) {
+	handshake := NewHandshake()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, handshake.Wait(ctx))
+
+	assert.True(t, handshake.Open())
+	assert.True(t, handshake.Open())
+	assert.False(t, handshake.Fail(errors.New("failed")))
+	assert.NoError(t, handshake.Wait(context.Background()))
+}
+
+func TestHandshakeFailure(t *testing.T) {
+	handshake := NewHandshake()
+
+	failure := errors.New("failed")
+	assert.True(t, handshake.Fail(failure))
+	assert.False(t, handshake.Open())
+	assert.Equal(t, failure, handshake.Wait(context.Background()))
+}
@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package atomix
+
+import (
+	"github.com/atomix/atomix-go-client/pkg/atomix/counter"
+	"github.com/atomix/atomix-go-client/pkg/atomix/election"
+	"github.com/atomix/atomix-go-client/pkg/atomix/indexedmap"
+	"github.com/atomix/atomix-go-client/pkg/atomix/list"
+	"github.com/atomix/atomix-go-client/pkg/atomix/lock"
+	_map "github.com/atomix/atomix-go-client/pkg/atomix/map"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"github.com/atomix/atomix-go-client/pkg/atomix/set"
+	"github.com/atomix/atomix-go-client/pkg/atomix/value"
+)
+
+// AsCounter returns the given primitive as a Counter if it is a counter
+func AsCounter(p primitive.Primitive) (counter.Counter, bool) {
+	if primitive.TypeOf(p) != counter.Type {
+		return nil, false
+	}
+	c, ok := p.(counter.Counter)
+	return c, ok
+}
+
+// AsElection returns the given primitive as an Election if it is an election
+func AsElection(p primitive.Primitive) (election.Election, bool) {
+	if primitive.TypeOf(p) != election.Type {
+		return nil, false
+	}
+	e, ok := p.(election.Election)
+	return e, ok
+}
+
+// AsIndexedMap returns the given primitive as an IndexedMap if it is an indexed map
+func AsIndexedMap(p primitive.Primitive) (indexedmap.IndexedMap, bool) {
+	if primitive.TypeOf(p) != indexedmap.Type {
+		return nil, false
+	}
+	m, ok := p.(indexedmap.IndexedMap)
+	return m, ok
+}
+
+// AsList returns the given primitive as a List if it is a list
+func AsList(p primitive.Primitive) (list.List, bool) {
+	if primitive.TypeOf(p) != list.Type {
+		return nil, false
+	}
+	l, ok := p.(list.List)
+	return l, ok
+}
+
+// AsLock returns the given primitive as a Lock if it is a lock
+func AsLock(p primitive.Primitive) (lock.Lock, bool) {
+	if primitive.TypeOf(p) != lock.Type {
+		return nil, false
+	}
+	l, ok := p.(lock.Lock)
+	return l, ok
+}
+
+// AsMap returns the given primitive as a Map if it is a map
+func AsMap(p primitive.Primitive) (_map.Map, bool) {
+	if primitive.TypeOf(p) != _map.Type {
+		return nil, false
+	}
+	m, ok := p.(_map.Map)
+	return m, ok
+}
+
+// AsSet returns the given primitive as a Set if it is a set
+func AsSet(p primitive.Primitive) (set.Set, bool) {
+	if primitive.TypeOf(p) != set.Type {
+		return nil, false
+	}
+	s, ok := p.(set.Set)
+	return s, ok
+}
+
+// AsValue returns the given primitive as a Value if it is a value
+func AsValue(p primitive.Primitive) (value.Value, bool) {
+	if primitive.TypeOf(p) != value.Type {
+		return nil, false
+	}
+	v, ok := p.(value.Value)
+	return v, ok
+}
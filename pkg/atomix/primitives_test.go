@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package atomix
+
+import (
+	"context"
+	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	"github.com/atomix/atomix-go-client/pkg/atomix/counter"
+	"github.com/atomix/atomix-go-client/pkg/atomix/election"
+	"github.com/atomix/atomix-go-client/pkg/atomix/indexedmap"
+	"github.com/atomix/atomix-go-client/pkg/atomix/list"
+	"github.com/atomix/atomix-go-client/pkg/atomix/lock"
+	_map "github.com/atomix/atomix-go-client/pkg/atomix/map"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"github.com/atomix/atomix-go-client/pkg/atomix/set"
+	"github.com/atomix/atomix-go-client/pkg/atomix/util/test"
+	"github.com/atomix/atomix-go-client/pkg/atomix/value"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"testing"
+)
+
+func TestPrimitiveAssertions(t *testing.T) {
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+	defer test.Stop()
+
+	constructors := map[primitive.Type]func(context.Context, string, *grpc.ClientConn, ...primitive.Option) (primitive.Primitive, error){
+		counter.Type: func(ctx context.Context, name string, conn *grpc.ClientConn, opts ...primitive.Option) (primitive.Primitive, error) {
+			return counter.New(ctx, name, conn, opts...)
+		},
+		election.Type: func(ctx context.Context, name string, conn *grpc.ClientConn, opts ...primitive.Option) (primitive.Primitive, error) {
+			return election.New(ctx, name, conn, opts...)
+		},
+		indexedmap.Type: func(ctx context.Context, name string, conn *grpc.ClientConn, opts ...primitive.Option) (primitive.Primitive, error) {
+			return indexedmap.New(ctx, name, conn, opts...)
+		},
+		list.Type: func(ctx context.Context, name string, conn *grpc.ClientConn, opts ...primitive.Option) (primitive.Primitive, error) {
+			return list.New(ctx, name, conn, opts...)
+		},
+		lock.Type: func(ctx context.Context, name string, conn *grpc.ClientConn, opts ...primitive.Option) (primitive.Primitive, error) {
+			return lock.New(ctx, name, conn, opts...)
+		},
+		_map.Type: func(ctx context.Context, name string, conn *grpc.ClientConn, opts ...primitive.Option) (primitive.Primitive, error) {
+			return _map.New(ctx, name, conn, opts...)
+		},
+		set.Type: func(ctx context.Context, name string, conn *grpc.ClientConn, opts ...primitive.Option) (primitive.Primitive, error) {
+			return set.New(ctx, name, conn, opts...)
+		},
+		value.Type: func(ctx context.Context, name string, conn *grpc.ClientConn, opts ...primitive.Option) (primitive.Primitive, error) {
+			return value.New(ctx, name, conn, opts...)
+		},
+	}
+
+	assertions := map[primitive.Type]func(primitive.Primitive) bool{
+		counter.Type: func(p primitive.Primitive) bool {
+			c, ok := AsCounter(p)
+			return ok && c != nil
+		},
+		election.Type: func(p primitive.Primitive) bool {
+			e, ok := AsElection(p)
+			return ok && e != nil
+		},
+		indexedmap.Type: func(p primitive.Primitive) bool {
+			m, ok := AsIndexedMap(p)
+			return ok && m != nil
+		},
+		list.Type: func(p primitive.Primitive) bool {
+			l, ok := AsList(p)
+			return ok && l != nil
+		},
+		lock.Type: func(p primitive.Primitive) bool {
+			l, ok := AsLock(p)
+			return ok && l != nil
+		},
+		_map.Type: func(p primitive.Primitive) bool {
+			m, ok := AsMap(p)
+			return ok && m != nil
+		},
+		set.Type: func(p primitive.Primitive) bool {
+			s, ok := AsSet(p)
+			return ok && s != nil
+		},
+		value.Type: func(p primitive.Primitive) bool {
+			v, ok := AsValue(p)
+			return ok && v != nil
+		},
+	}
+
+	for primitiveType, constructor := range constructors {
+		conn, err := test.CreateProxy(primitiveapi.PrimitiveId{
+			Type:      primitiveType.String(),
+			Namespace: "test",
+			Name:      "TestPrimitiveAssertions",
+		})
+		assert.NoError(t, err)
+		p, err := constructor(context.TODO(), "TestPrimitiveAssertions", conn)
+		assert.NoError(t, err)
+		assert.Equal(t, primitiveType, primitive.TypeOf(p))
+
+		// Each primitive can be narrowed only to its own type
+		for assertionType, assertion := range assertions {
+			assert.Equal(t, assertionType == primitiveType, assertion(p), "%s as %s", primitiveType, assertionType)
+		}
+		assert.NoError(t, p.Close(context.TODO()))
+	}
+
+	// A nil primitive cannot be narrowed to any type
+	assert.Equal(t, primitive.Type(""), primitive.TypeOf(nil))
+	for assertionType, assertion := range assertions {
+		assert.False(t, assertion(nil), "nil as %s", assertionType)
+	}
+}
@@ -38,3 +38,151 @@ func (o replayOption) beforeWatch(request *api.EventsRequest) {
 func (o replayOption) afterWatch(response *api.EventsResponse) {
 
 }
+
+// ElementsOption is an option for set Elements calls
+type ElementsOption interface {
+	beforeElements(request *api.ElementsRequest)
+	afterElements(response *api.ElementsResponse)
+}
+
+// WithFilter returns an option that delivers only the values matching the given predicate
+// The predicate is applied to values as they are received from the server. Filtered watches deliver
+// events only for matching values, including replayed values. If the predicate panics, the stream is closed
+// and an error matching errors.ErrPanic is passed to the listener set with WithErrorListener, if any.
+func WithFilter(filter func(string) bool) FilterOption {
+	return FilterOption{filter: filter}
+}
+
+// FilterOption is an option for filtering the values delivered by Elements and Watch calls
+type FilterOption struct {
+	filter func(string) bool
+}
+
+func (o FilterOption) beforeElements(request *api.ElementsRequest) {
+
+}
+
+func (o FilterOption) afterElements(response *api.ElementsResponse) {
+
+}
+
+func (o FilterOption) beforeWatch(request *api.EventsRequest) {
+
+}
+
+func (o FilterOption) afterWatch(response *api.EventsResponse) {
+
+}
+
+// WithErrorListener returns an option that notifies the given listener of the error that closed the stream
+// The listener is called once, before the channel is closed, if the stream fails or a filter panics. It is not
+// called when the stream ends normally or its context is done.
+func WithErrorListener(listener func(error)) ErrorListenerOption {
+	return ErrorListenerOption{listener: listener}
+}
+
+// ErrorListenerOption is an option for receiving the error that closed an Elements or Watch stream
+type ErrorListenerOption struct {
+	listener func(error)
+}
+
+func (o ErrorListenerOption) beforeElements(request *api.ElementsRequest) {
+
+}
+
+func (o ErrorListenerOption) afterElements(response *api.ElementsResponse) {
+
+}
+
+func (o ErrorListenerOption) beforeWatch(request *api.EventsRequest) {
+
+}
+
+func (o ErrorListenerOption) afterWatch(response *api.EventsResponse) {
+
+}
+
+// ImportOption is an option for set Import calls
+type ImportOption interface {
+	applyImport(options *importOptions)
+}
+
+// importOptions is a set of Import options
+type importOptions struct {
+	clearFirst bool
+	idempotent bool
+	batchSize  int
+}
+
+// WithClearFirst returns an Import option that clears the set before the elements are added
+// The set is not cleared atomically with the import, so elements added concurrently may be removed or
+// retained.
+func WithClearFirst() ImportOption {
+	return clearFirstOption{}
+}
+
+type clearFirstOption struct{}
+
+func (o clearFirstOption) applyImport(options *importOptions) {
+	options.clearFirst = true
+}
+
+// WithIdempotent returns an Import option that ignores elements that are already in the set
+func WithIdempotent() ImportOption {
+	return idempotentOption{}
+}
+
+type idempotentOption struct{}
+
+func (o idempotentOption) applyImport(options *importOptions) {
+	options.idempotent = true
+}
+
+// WithBatchSize returns an Import option that sets the maximum number of elements added concurrently
+func WithBatchSize(size int) ImportOption {
+	return batchSizeOption{size: size}
+}
+
+type batchSizeOption struct {
+	size int
+}
+
+func (o batchSizeOption) applyImport(options *importOptions) {
+	options.batchSize = o.size
+}
+
+// ReconcileOption is an option for the ReconcileTo method
+type ReconcileOption interface {
+	applyReconcile(options *reconcileOptions)
+}
+
+// reconcileOptions is a set of ReconcileTo options
+type reconcileOptions struct {
+	dryRun      bool
+	parallelism int
+}
+
+// WithDryRun computes the changes required to reconcile the set without applying them
+// The number of elements that would be added and removed is returned.
+func WithDryRun() ReconcileOption {
+	return dryRunOption{}
+}
+
+type dryRunOption struct{}
+
+func (o dryRunOption) applyReconcile(options *reconcileOptions) {
+	options.dryRun = true
+}
+
+// WithParallelism sets the maximum number of changes to apply concurrently
+func WithParallelism(parallelism int) ReconcileOption {
+	return parallelismOption{parallelism: parallelism}
+}
+
+type parallelismOption struct {
+	parallelism int
+}
+
+func (o parallelismOption) applyReconcile(options *reconcileOptions) {
+	options.parallelism = o.parallelism
+}
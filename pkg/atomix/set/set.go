@@ -6,12 +6,17 @@ package set
 
 import (
 	"context"
+	"fmt"
 	api "github.com/atomix/atomix-api/go/atomix/primitive/set"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
-	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/time"
 	"google.golang.org/grpc"
 	"io"
+	"sort"
+	"strings"
+	"sync"
 )
 
 var log = logging.GetLogger("atomix", "client", "set")
@@ -48,7 +53,25 @@ type Set interface {
 	Clear(ctx context.Context) error
 
 	// Elements lists the elements in the set
-	Elements(ctx context.Context, ch chan<- string) error
+	Elements(ctx context.Context, ch chan<- string, opts ...ElementsOption) error
+
+	// Export returns all the elements in the set
+	// Unlike Elements, Export returns an error if the elements cannot be read in full.
+	Export(ctx context.Context) ([]string, error)
+
+	// Import adds the given elements to the set
+	// Elements are added concurrently in batches. By default, elements already in the set are reported as
+	// AlreadyExists errors; pass WithIdempotent to ignore them. If any element cannot be added, a ValueErrors
+	// error is returned mapping each failed element to its error.
+	Import(ctx context.Context, elements []string, opts ...ImportOption) error
+
+	// ReconcileTo applies the changes required to bring the set to the desired membership
+	// The set's elements are read and compared against the desired elements; elements that are missing are
+	// added and elements that are not desired are removed, and elements already in the desired state are not
+	// modified. The number of elements that were added and removed is returned; elements added or removed
+	// concurrently by another client are not counted. If any change cannot be applied, a ValueErrors error
+	// is returned mapping each failed element to its error.
+	ReconcileTo(ctx context.Context, desired []string, opts ...ReconcileOption) (added, removed int, err error)
 
 	// Watch watches the set for changes
 	// This is a non-blocking method. If the method returns without error, set events will be pushed onto
@@ -70,6 +93,28 @@ const (
 	EventReplay EventType = "replay"
 )
 
+// defaultImportBatchSize is the default maximum number of elements added concurrently by Import
+const defaultImportBatchSize = 10
+
+// defaultReconcileParallelism is the default maximum number of concurrent changes applied by ReconcileTo
+const defaultReconcileParallelism = 10
+
+// ValueErrors is an error mapping each value for which an operation failed to its error
+type ValueErrors map[string]error
+
+func (e ValueErrors) Error() string {
+	values := make([]string, 0, len(e))
+	for value := range e {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	messages := make([]string, len(values))
+	for i, value := range values {
+		messages[i] = fmt.Sprintf("%s: %v", value, e[value])
+	}
+	return strings.Join(messages, "; ")
+}
+
 // Event is a set change event
 type Event struct {
 	// Type is the change event type
@@ -77,6 +122,9 @@ type Event struct {
 
 	// Value is the value that changed
 	Value string
+
+	// Timestamp is the server time at which the element was added or removed, as read by primitive.GetTimestamp
+	Timestamp time.Timestamp
 }
 
 // New creates a new partitioned set primitive
@@ -176,16 +224,30 @@ func (s *set) Clear(ctx context.Context) error {
 	return nil
 }
 
-func (s *set) Elements(ctx context.Context, ch chan<- string) error {
+func (s *set) Elements(ctx context.Context, ch chan<- string, opts ...ElementsOption) error {
 	request := &api.ElementsRequest{
 		Headers: s.GetHeaders(),
 	}
-	stream, err := s.client.Elements(ctx, request)
+	var filters []FilterOption
+	var listeners []ErrorListenerOption
+	for i := range opts {
+		opts[i].beforeElements(request)
+		if filter, ok := opts[i].(FilterOption); ok {
+			filters = append(filters, filter)
+		}
+		if listener, ok := opts[i].(ErrorListenerOption); ok {
+			listeners = append(listeners, listener)
+		}
+	}
+	streamCtx, cancel := context.WithCancel(ctx)
+	stream, err := s.client.Elements(streamCtx, request)
 	if err != nil {
+		cancel()
 		return errors.From(err)
 	}
 
 	go func() {
+		defer cancel()
 		defer close(ch)
 		for {
 			response, err := stream.Recv()
@@ -198,37 +260,206 @@ func (s *set) Elements(ctx context.Context, ch chan<- string) error {
 					return
 				}
 				log.Errorf("Elements failed: %v", err)
+				notifyError(listeners, err)
 				return
 			}
 
-			ch <- response.Element.Value
+			for i := range opts {
+				opts[i].afterElements(response)
+			}
+			match, err := s.matches(response.Element.Value, filters)
+			if err != nil {
+				log.Errorf("Elements failed: %v", err)
+				notifyError(listeners, err)
+				return
+			}
+			if match {
+				ch <- response.Element.Value
+			}
 		}
 	}()
 	return nil
 }
 
+func (s *set) Export(ctx context.Context) ([]string, error) {
+	request := &api.ElementsRequest{
+		Headers: s.GetHeaders(),
+	}
+	stream, err := s.client.Elements(ctx, request)
+	if err != nil {
+		return nil, errors.From(err)
+	}
+
+	elements := make([]string, 0)
+	for {
+		response, err := stream.Recv()
+		if err == io.EOF {
+			return elements, nil
+		}
+		if err != nil {
+			return nil, errors.From(err)
+		}
+		elements = append(elements, response.Element.Value)
+	}
+}
+
+func (s *set) Import(ctx context.Context, elements []string, opts ...ImportOption) error {
+	options := importOptions{
+		batchSize: defaultImportBatchSize,
+	}
+	for _, opt := range opts {
+		opt.applyImport(&options)
+	}
+	if options.batchSize <= 0 {
+		return errors.NewInvalid("batch size must be positive")
+	}
+
+	if options.clearFirst {
+		if err := s.Clear(ctx); err != nil {
+			return err
+		}
+	}
+
+	errs := make(ValueErrors)
+	mu := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	sem := make(chan struct{}, options.batchSize)
+	for _, e := range elements {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(element string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			request := &api.AddRequest{
+				Headers: s.GetHeaders(),
+				Element: api.Element{
+					Value: element,
+				},
+			}
+			if _, err := s.client.Add(ctx, request); err != nil {
+				err = errors.From(err)
+				if options.idempotent && errors.IsAlreadyExists(err) {
+					return
+				}
+				mu.Lock()
+				errs[element] = err
+				mu.Unlock()
+			}
+		}(e)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (s *set) ReconcileTo(ctx context.Context, desired []string, opts ...ReconcileOption) (int, int, error) {
+	options := reconcileOptions{
+		parallelism: defaultReconcileParallelism,
+	}
+	for _, opt := range opts {
+		opt.applyReconcile(&options)
+	}
+	if options.parallelism <= 0 {
+		return 0, 0, errors.NewInvalid("parallelism must be positive")
+	}
+
+	elements, err := s.Export(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	current := make(map[string]bool)
+	for _, element := range elements {
+		current[element] = true
+	}
+	wanted := make(map[string]bool)
+	var toAdd []string
+	for _, element := range desired {
+		if !wanted[element] && !current[element] {
+			toAdd = append(toAdd, element)
+		}
+		wanted[element] = true
+	}
+	var toRemove []string
+	for _, element := range elements {
+		if !wanted[element] {
+			toRemove = append(toRemove, element)
+		}
+	}
+
+	if options.dryRun {
+		return len(toAdd), len(toRemove), nil
+	}
+
+	added, removed := 0, 0
+	errs := make(ValueErrors)
+	mu := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	sem := make(chan struct{}, options.parallelism)
+	apply := func(element string, f func(context.Context, string) (bool, error), count *int) {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			// Elements already in the desired state are not counted as changes
+			changed, err := f(ctx, element)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[element] = err
+			} else if changed {
+				*count++
+			}
+		}()
+	}
+	for _, element := range toAdd {
+		apply(element, s.Add, &added)
+	}
+	for _, element := range toRemove {
+		apply(element, s.Remove, &removed)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return added, removed, errs
+	}
+	return added, removed, nil
+}
+
 func (s *set) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption) error {
 	request := &api.EventsRequest{
 		Headers: s.GetHeaders(),
 	}
+	var filters []FilterOption
+	var listeners []ErrorListenerOption
 	for i := range opts {
 		opts[i].beforeWatch(request)
+		if filter, ok := opts[i].(FilterOption); ok {
+			filters = append(filters, filter)
+		}
+		if listener, ok := opts[i].(ErrorListenerOption); ok {
+			listeners = append(listeners, listener)
+		}
 	}
 
-	stream, err := s.client.Events(ctx, request)
+	streamCtx, cancel := context.WithCancel(ctx)
+	stream, err := s.client.Events(streamCtx, request)
 	if err != nil {
+		cancel()
 		return errors.From(err)
 	}
 
-	openCh := make(chan struct{})
+	handshake := primitive.NewHandshake()
 	go func() {
+		defer cancel()
 		defer close(ch)
-		open := false
-		defer func() {
-			if !open {
-				close(openCh)
-			}
-		}()
+		defer handshake.Open()
 		for {
 			response, err := stream.Recv()
 			if err != nil {
@@ -236,45 +467,81 @@ func (s *set) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption) e
 					return
 				}
 				err = errors.From(err)
+				// A stream that fails before it is opened fails the watch
+				if handshake.Fail(err) {
+					return
+				}
 				if errors.IsCanceled(err) || errors.IsTimeout(err) {
 					return
 				}
 				log.Errorf("Watch failed: %v", err)
+				notifyError(listeners, err)
 				return
 			}
 
-			if !open {
-				close(openCh)
-				open = true
+			handshake.Open()
+			if response.Event.Type == api.Event_NONE {
+				continue
 			}
+			timestamp := primitive.GetTimestamp(response.Headers)
 			for i := range opts {
 				opts[i].afterWatch(response)
 			}
+			match, err := s.matches(response.Event.Element.Value, filters)
+			if err != nil {
+				log.Errorf("Watch failed: %v", err)
+				notifyError(listeners, err)
+				return
+			}
+			if !match {
+				continue
+			}
 
 			switch response.Event.Type {
 			case api.Event_ADD:
 				ch <- Event{
-					Type:  EventAdd,
-					Value: response.Event.Element.Value,
+					Type:      EventAdd,
+					Value:     response.Event.Element.Value,
+					Timestamp: timestamp,
 				}
 			case api.Event_REMOVE:
 				ch <- Event{
-					Type:  EventRemove,
-					Value: response.Event.Element.Value,
+					Type:      EventRemove,
+					Value:     response.Event.Element.Value,
+					Timestamp: timestamp,
 				}
 			case api.Event_REPLAY:
 				ch <- Event{
-					Type:  EventReplay,
-					Value: response.Event.Element.Value,
+					Type:      EventReplay,
+					Value:     response.Event.Element.Value,
+					Timestamp: timestamp,
 				}
 			}
 		}
 	}()
 
-	select {
-	case <-openCh:
-		return nil
-	case <-ctx.Done():
-		return ctx.Err()
+	return handshake.Wait(ctx)
+}
+
+// notifyError notifies the given listeners of the error that closed a stream
+func notifyError(listeners []ErrorListenerOption, err error) {
+	for _, listener := range listeners {
+		listener.listener(err)
 	}
 }
+
+// matches returns whether the given value matches all the given filters
+// An error is returned if a filter panics.
+func (s *set) matches(value string, filters []FilterOption) (bool, error) {
+	match := true
+	err := s.InvokeCallback(func() error {
+		for _, filter := range filters {
+			if !filter.filter(value) {
+				match = false
+				return nil
+			}
+		}
+		return nil
+	})
+	return match, err
+}
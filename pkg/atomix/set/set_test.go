@@ -7,9 +7,11 @@ package set
 import (
 	"context"
 	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-client/pkg/atomix/util/test"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 )
 
@@ -162,3 +164,325 @@ func TestSetOperations(t *testing.T) {
 
 	assert.NoError(t, test.Stop())
 }
+
+func TestSetWatchOpen(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestSetWatchOpen",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	set, err := New(context.TODO(), "TestSetWatchOpen", conn)
+	assert.NoError(t, err)
+
+	ch := make(chan Event)
+	err = set.Watch(context.TODO(), ch)
+	assert.NoError(t, err)
+
+	_, err = set.Add(context.TODO(), "foo")
+	assert.NoError(t, err)
+
+	// The open marker completes the handshake and must not be delivered to the consumer
+	event := <-ch
+	assert.Equal(t, EventAdd, event.Type)
+	assert.Equal(t, "foo", event.Value)
+
+	assert.NoError(t, set.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+func TestSetWatchEvents(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestSetWatchEvents",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	set, err := New(context.TODO(), "TestSetWatchEvents", conn)
+	assert.NoError(t, err)
+
+	_, err = set.Add(context.TODO(), "foo")
+	assert.NoError(t, err)
+	_, err = set.Add(context.TODO(), "bar")
+	assert.NoError(t, err)
+	_, err = set.Remove(context.TODO(), "foo")
+	assert.NoError(t, err)
+
+	events := make(chan Event)
+	err = set.Watch(context.TODO(), events, WithReplay())
+	assert.NoError(t, err)
+
+	// Current members are replayed before any changes
+	event := <-events
+	assert.Equal(t, EventReplay, event.Type)
+	assert.Equal(t, "bar", event.Value)
+
+	_, err = set.Add(context.TODO(), "baz")
+	assert.NoError(t, err)
+
+	event = <-events
+	assert.Equal(t, EventAdd, event.Type)
+	assert.Equal(t, "baz", event.Value)
+
+	_, err = set.Remove(context.TODO(), "bar")
+	assert.NoError(t, err)
+
+	event = <-events
+	assert.Equal(t, EventRemove, event.Type)
+	assert.Equal(t, "bar", event.Value)
+
+	// Removing an element that is not in the set does not produce an event
+	removed, err := set.Remove(context.TODO(), "bar")
+	assert.NoError(t, err)
+	assert.False(t, removed)
+
+	_, err = set.Add(context.TODO(), "qux")
+	assert.NoError(t, err)
+
+	event = <-events
+	assert.Equal(t, EventAdd, event.Type)
+	assert.Equal(t, "qux", event.Value)
+
+	assert.NoError(t, set.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+func TestSetFilter(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestSetFilter",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	set, err := New(context.TODO(), "TestSetFilter", conn)
+	assert.NoError(t, err)
+
+	for _, value := range []string{"a1", "b1", "a2", "b2"} {
+		_, err = set.Add(context.TODO(), value)
+		assert.NoError(t, err)
+	}
+
+	isA := func(value string) bool {
+		return strings.HasPrefix(value, "a")
+	}
+
+	// Only matching elements are listed
+	ch := make(chan string)
+	err = set.Elements(context.TODO(), ch, WithFilter(isA))
+	assert.NoError(t, err)
+	var elements []string
+	for element := range ch {
+		elements = append(elements, element)
+	}
+	assert.ElementsMatch(t, []string{"a1", "a2"}, elements)
+
+	// Only matching elements are replayed and watched
+	events := make(chan Event)
+	err = set.Watch(context.TODO(), events, WithReplay(), WithFilter(isA))
+	assert.NoError(t, err)
+
+	var replayed []string
+	for i := 0; i < 2; i++ {
+		event := <-events
+		assert.Equal(t, EventReplay, event.Type)
+		replayed = append(replayed, event.Value)
+	}
+	assert.ElementsMatch(t, []string{"a1", "a2"}, replayed)
+
+	_, err = set.Add(context.TODO(), "b3")
+	assert.NoError(t, err)
+	_, err = set.Remove(context.TODO(), "b1")
+	assert.NoError(t, err)
+	_, err = set.Add(context.TODO(), "a3")
+	assert.NoError(t, err)
+
+	event := <-events
+	assert.Equal(t, EventAdd, event.Type)
+	assert.Equal(t, "a3", event.Value)
+
+	_, err = set.Remove(context.TODO(), "a1")
+	assert.NoError(t, err)
+
+	event = <-events
+	assert.Equal(t, EventRemove, event.Type)
+	assert.Equal(t, "a1", event.Value)
+
+	// A panicking filter closes the stream, the panic is passed to the error listener, and the set remains usable
+	panics := func(value string) bool {
+		panic("filter failed")
+	}
+	var elementsErr error
+	ch = make(chan string)
+	err = set.Elements(context.TODO(), ch, WithFilter(panics), WithErrorListener(func(err error) {
+		elementsErr = err
+	}))
+	assert.NoError(t, err)
+	for range ch {
+		t.Error("element delivered after panic")
+	}
+	assert.True(t, errors.IsPanic(elementsErr))
+
+	var watchErr error
+	events = make(chan Event)
+	err = set.Watch(context.TODO(), events, WithReplay(), WithFilter(panics), WithErrorListener(func(err error) {
+		watchErr = err
+	}))
+	assert.NoError(t, err)
+	for range events {
+		t.Error("event delivered after panic")
+	}
+	assert.True(t, errors.IsPanic(watchErr))
+	size, err := set.Len(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 4, size)
+
+	assert.NoError(t, set.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+func TestSetExportImport(t *testing.T) {
+	primitiveID1 := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestSetExport",
+	}
+	primitiveID2 := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestSetImport",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn1, err := test.CreateProxy(primitiveID1)
+	assert.NoError(t, err)
+	conn2, err := test.CreateProxy(primitiveID2)
+	assert.NoError(t, err)
+
+	set1, err := New(context.TODO(), "TestSetExport", conn1)
+	assert.NoError(t, err)
+	set2, err := New(context.TODO(), "TestSetImport", conn2)
+	assert.NoError(t, err)
+
+	elements, err := set1.Export(context.TODO())
+	assert.NoError(t, err)
+	assert.Len(t, elements, 0)
+
+	for _, value := range []string{"foo", "bar", "baz"} {
+		_, err = set1.Add(context.TODO(), value)
+		assert.NoError(t, err)
+	}
+	elements, err = set1.Export(context.TODO())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"foo", "bar", "baz"}, elements)
+
+	// Elements are round-tripped through export and import
+	assert.NoError(t, set2.Import(context.TODO(), elements, WithBatchSize(2)))
+	imported, err := set2.Export(context.TODO())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, elements, imported)
+
+	// Importing elements already in the set fails unless the import is idempotent
+	err = set2.Import(context.TODO(), []string{"foo", "qux"})
+	assert.Error(t, err)
+	valueErrs, ok := err.(ValueErrors)
+	assert.True(t, ok)
+	assert.Len(t, valueErrs, 1)
+	assert.True(t, errors.IsAlreadyExists(valueErrs["foo"]))
+
+	assert.NoError(t, set2.Import(context.TODO(), []string{"foo", "bar"}, WithIdempotent()))
+	imported, err = set2.Export(context.TODO())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"foo", "bar", "baz", "qux"}, imported)
+
+	// The set can be cleared before the import to restore it to the exported state
+	assert.NoError(t, set2.Import(context.TODO(), []string{"bar"}, WithClearFirst()))
+	imported, err = set2.Export(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bar"}, imported)
+
+	assert.Error(t, set2.Import(context.TODO(), elements, WithBatchSize(0)))
+
+	assert.NoError(t, set1.Close(context.Background()))
+	assert.NoError(t, set2.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+func TestSetReconcileTo(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestSetReconcileTo",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	set, err := New(context.TODO(), "TestSetReconcileTo", conn)
+	assert.NoError(t, err)
+
+	assert.NoError(t, set.Import(context.TODO(), []string{"foo", "bar", "baz"}))
+
+	desired := []string{"foo", "qux", "quux", "qux"}
+
+	// A dry run counts the planned changes without applying them
+	added, removed, err := set.ReconcileTo(context.TODO(), desired, WithDryRun())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, added)
+	assert.Equal(t, 2, removed)
+	elements, err := set.Export(context.TODO())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"foo", "bar", "baz"}, elements)
+
+	// Reconciling applies the planned changes
+	added, removed, err = set.ReconcileTo(context.TODO(), desired, WithParallelism(2))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, added)
+	assert.Equal(t, 2, removed)
+	elements, err = set.Export(context.TODO())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"foo", "qux", "quux"}, elements)
+
+	// The set has converged to the desired membership
+	added, removed, err = set.ReconcileTo(context.TODO(), desired)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, added)
+	assert.Equal(t, 0, removed)
+
+	// Reconciling to an empty membership removes all elements
+	added, removed, err = set.ReconcileTo(context.TODO(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, added)
+	assert.Equal(t, 3, removed)
+	size, err := set.Len(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, size)
+
+	_, _, err = set.ReconcileTo(context.TODO(), desired, WithParallelism(0))
+	assert.True(t, errors.IsInvalid(err))
+
+	assert.NoError(t, set.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package atomix
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultShutdownTimeout is the default maximum time Close waits for streams to drain
+const defaultShutdownTimeout = 5 * time.Second
+
+// CloseErrors is the set of errors that occurred while closing a client
+// Errors are ordered by the shutdown step in which they occurred: primitive sessions first, then connections.
+type CloseErrors []error
+
+func (e CloseErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// newStreamTracker creates a new tracker of open streams
+func newStreamTracker() *streamTracker {
+	return &streamTracker{}
+}
+
+// streamTracker tracks the streams opened on a client's connections so shutdown can wait for them to drain
+type streamTracker struct {
+	mu      sync.Mutex
+	open    int
+	drained chan struct{}
+}
+
+// add records an opened stream
+func (t *streamTracker) add() {
+	t.mu.Lock()
+	t.open++
+	t.mu.Unlock()
+}
+
+// done records a stream that has ended
+func (t *streamTracker) done() {
+	t.mu.Lock()
+	t.open--
+	if t.open == 0 && t.drained != nil {
+		close(t.drained)
+		t.drained = nil
+	}
+	t.mu.Unlock()
+}
+
+// wait waits for all open streams to end or for the context to be done
+func (t *streamTracker) wait(ctx context.Context) error {
+	t.mu.Lock()
+	if t.open == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	if t.drained == nil {
+		t.drained = make(chan struct{})
+	}
+	drained := t.drained
+	t.mu.Unlock()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// interceptor returns an interceptor that tracks each stream until it ends
+// A stream ends once receiving from it fails, which includes the end of the stream, or once its context is
+// done, so streams abandoned by cancelling their context without being drained are not tracked forever.
+func (t *streamTracker) interceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, err
+		}
+		t.add()
+		tracked := &trackedStream{ClientStream: stream, tracker: t}
+		go func() {
+			<-stream.Context().Done()
+			tracked.done()
+		}()
+		return tracked, nil
+	}
+}
+
+// trackedStream is a stream tracked by a streamTracker
+type trackedStream struct {
+	grpc.ClientStream
+	tracker *streamTracker
+	once    sync.Once
+}
+
+func (s *trackedStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.done()
+	}
+	return err
+}
+
+// done records the end of the stream
+func (s *trackedStream) done() {
+	s.once.Do(s.tracker.done)
+}
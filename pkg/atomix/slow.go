@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package atomix
+
+import (
+	"context"
+	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	"google.golang.org/grpc"
+	"strings"
+	"time"
+)
+
+// newSlowOperationLogger creates a new logger of operations that take longer than the given threshold
+func newSlowOperationLogger(threshold time.Duration) *slowOperationLogger {
+	return &slowOperationLogger{
+		threshold: threshold,
+		warnf:     log.Warnf,
+	}
+}
+
+// slowOperationLogger logs a warning for each primitive operation that takes longer than a threshold
+type slowOperationLogger struct {
+	threshold time.Duration
+	warnf     func(msg string, args ...interface{})
+}
+
+// headersRequest is a primitive request carrying request headers
+type headersRequest interface {
+	GetHeaders() primitiveapi.RequestHeaders
+}
+
+// observe logs a warning if the operation started at the given time has exceeded the threshold
+func (l *slowOperationLogger) observe(method string, req interface{}, start time.Time) {
+	elapsed := time.Since(start)
+	if elapsed < l.threshold {
+		return
+	}
+	operation := method[strings.LastIndex(method, "/")+1:]
+	if request, ok := req.(headersRequest); ok {
+		id := request.GetHeaders().PrimitiveID
+		l.warnf("Slow operation %s on %s %s took %s", operation, id.Type, id.Name, elapsed)
+	} else {
+		l.warnf("Slow operation %s took %s", operation, elapsed)
+	}
+}
+
+// interceptors returns interceptors that time each request
+// Unary requests are timed until the response is received, including any retries. Streams are timed only
+// while the stream is being opened, so long-lived streams such as watches are not reported as slow.
+func (l *slowOperationLogger) interceptors() (grpc.UnaryClientInterceptor, grpc.StreamClientInterceptor) {
+	unary := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		defer l.observe(method, req, time.Now())
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	stream := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		defer l.observe(method, nil, time.Now())
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+	return unary, stream
+}
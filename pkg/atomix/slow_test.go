@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package atomix
+
+import (
+	"context"
+	"fmt"
+	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	mapapi "github.com/atomix/atomix-api/go/atomix/primitive/map"
+	_map "github.com/atomix/atomix-go-client/pkg/atomix/map"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testSlowMapServer is a map server whose Size requests are delayed
+type testSlowMapServer struct {
+	mapapi.UnimplementedMapServiceServer
+	delay time.Duration
+}
+
+func (s *testSlowMapServer) Size(ctx context.Context, request *mapapi.SizeRequest) (*mapapi.SizeResponse, error) {
+	time.Sleep(s.delay)
+	return &mapapi.SizeResponse{}, nil
+}
+
+func (s *testSlowMapServer) Get(ctx context.Context, request *mapapi.GetRequest) (*mapapi.GetResponse, error) {
+	return &mapapi.GetResponse{
+		Entry: mapapi.Entry{
+			Key: mapapi.Key{
+				Key: request.Key,
+			},
+			Value: &mapapi.Value{},
+		},
+	}, nil
+}
+
+func TestSlowThreshold(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := grpc.NewServer()
+	primitiveapi.RegisterPrimitiveServer(server, &testPrimitiveServer{})
+	mapapi.RegisterMapServiceServer(server, &testSlowMapServer{delay: 100 * time.Millisecond})
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	defer server.Stop()
+
+	client := NewClient(WithSlowThreshold(50 * time.Millisecond)).(*atomixClient)
+	var warnings []string
+	mu := &sync.Mutex{}
+	client.slowOps.warnf = func(msg string, args ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		warnings = append(warnings, fmt.Sprintf(msg, args...))
+	}
+	conn, err := grpc.Dial(lis.Addr().String(), client.getPrimitiveDialOptions()...)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	m, err := _map.New(context.Background(), "TestSlowThreshold", conn)
+	assert.NoError(t, err)
+
+	// Fast operations are not logged
+	_, err = m.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	mu.Lock()
+	assert.Len(t, warnings, 0)
+	mu.Unlock()
+
+	// Slow operations are logged with the primitive, operation and elapsed time
+	_, err = m.Len(context.Background())
+	assert.NoError(t, err)
+	mu.Lock()
+	assert.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "Slow operation Size on Map TestSlowThreshold took ")
+	elapsed, err := time.ParseDuration(warnings[0][len("Slow operation Size on Map TestSlowThreshold took "):])
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, int64(elapsed), int64(100*time.Millisecond))
+	mu.Unlock()
+
+	// Operations are not logged unless a threshold is set
+	assert.Nil(t, NewClient().(*atomixClient).slowOps)
+}
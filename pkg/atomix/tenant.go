@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package atomix
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// TenantKey is the outgoing metadata key under which the tenant identifier is sent
+const TenantKey = "atomix-tenant"
+
+// getTenantDialOptions returns the dial options for attaching the given tenant to outgoing requests
+func getTenantDialOptions(tenant string) []grpc.DialOption {
+	unary := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(withTenant(ctx, tenant), method, req, reply, cc, opts...)
+	}
+	stream := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(withTenant(ctx, tenant), desc, cc, method, opts...)
+	}
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(unary),
+		grpc.WithChainStreamInterceptor(stream),
+	}
+}
+
+// withTenant attaches the given tenant to the outgoing metadata of the given context
+// If the context already carries a tenant, it is not replaced.
+func withTenant(ctx context.Context, tenant string) context.Context {
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(TenantKey)) > 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, TenantKey, tenant)
+}
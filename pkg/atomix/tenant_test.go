@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package atomix
+
+import (
+	"context"
+	brokerapi "github.com/atomix/atomix-api/go/atomix/management/broker"
+	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	counterapi "github.com/atomix/atomix-api/go/atomix/primitive/counter"
+	mapapi "github.com/atomix/atomix-api/go/atomix/primitive/map"
+	"github.com/atomix/atomix-go-client/pkg/atomix/counter"
+	_map "github.com/atomix/atomix-go-client/pkg/atomix/map"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"net"
+	"sync"
+	"testing"
+)
+
+// testTenantServer is a primitive server that records the tenant of each request by method
+type testTenantServer struct {
+	primitiveapi.UnimplementedPrimitiveServer
+	mapapi.UnimplementedMapServiceServer
+	tenants map[string][]string
+	mu      sync.Mutex
+}
+
+func (s *testTenantServer) record(ctx context.Context, method string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.mu.Lock()
+	s.tenants[method] = append(s.tenants[method], md.Get(TenantKey)...)
+	s.mu.Unlock()
+}
+
+func (s *testTenantServer) get(method string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tenants[method]
+}
+
+func (s *testTenantServer) Create(ctx context.Context, request *primitiveapi.CreateRequest) (*primitiveapi.CreateResponse, error) {
+	return &primitiveapi.CreateResponse{}, nil
+}
+
+func (s *testTenantServer) Put(ctx context.Context, request *mapapi.PutRequest) (*mapapi.PutResponse, error) {
+	return &mapapi.PutResponse{
+		Entry: mapapi.Entry{
+			Key:   request.Entry.Key,
+			Value: request.Entry.Value,
+		},
+	}, nil
+}
+
+func (s *testTenantServer) Entries(request *mapapi.EntriesRequest, stream mapapi.MapService_EntriesServer) error {
+	return nil
+}
+
+// testTenantCounterServer is a counter server
+type testTenantCounterServer struct {
+	counterapi.UnimplementedCounterServiceServer
+}
+
+func (s *testTenantCounterServer) Get(ctx context.Context, request *counterapi.GetRequest) (*counterapi.GetResponse, error) {
+	return &counterapi.GetResponse{}, nil
+}
+
+// testTenantBroker is a broker that records the tenant of each lookup
+type testTenantBroker struct {
+	*testBroker
+	tenants []string
+	mu      sync.Mutex
+}
+
+func (b *testTenantBroker) LookupPrimitive(ctx context.Context, request *brokerapi.LookupPrimitiveRequest) (*brokerapi.LookupPrimitiveResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	b.mu.Lock()
+	b.tenants = append(b.tenants, md.Get(TenantKey)...)
+	b.mu.Unlock()
+	return b.testBroker.LookupPrimitive(ctx, request)
+}
+
+func (b *testTenantBroker) get() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tenants
+}
+
+func TestTenant(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	primitiveServer := &testTenantServer{
+		tenants: make(map[string][]string),
+	}
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			primitiveServer.record(ctx, info.FullMethod)
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			primitiveServer.record(stream.Context(), info.FullMethod)
+			return handler(srv, stream)
+		}))
+	primitiveapi.RegisterPrimitiveServer(server, primitiveServer)
+	mapapi.RegisterMapServiceServer(server, primitiveServer)
+	counterapi.RegisterCounterServiceServer(server, &testTenantCounterServer{})
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	defer server.Stop()
+
+	broker := &testTenantBroker{
+		testBroker: &testBroker{
+			primitives: map[brokerapi.PrimitiveId]bool{
+				{PrimitiveId: newPrimitiveID(_map.Type, "my-map")}:        true,
+				{PrimitiveId: newPrimitiveID(counter.Type, "my-counter")}: true,
+			},
+			port: lis.Addr().(*net.TCPAddr).Port,
+		},
+	}
+	port, stop := startTestBroker(t, broker)
+	defer stop()
+
+	client := NewClient(WithBrokerHost("127.0.0.1"), WithBrokerPort(port), WithTenant("foo"))
+	defer client.Close()
+
+	// The client's tenant is attached to requests for all primitives
+	myMap, err := client.GetMap(context.Background(), "my-map")
+	assert.NoError(t, err)
+	_, err = myMap.Put(context.Background(), "foo", []byte("bar"))
+	assert.NoError(t, err)
+	ch := make(chan _map.Entry)
+	assert.NoError(t, myMap.Entries(context.Background(), ch))
+	for range ch {
+	}
+
+	assert.Equal(t, []string{"foo"}, primitiveServer.get("/atomix.primitive.map.MapService/Put"))
+	assert.Equal(t, []string{"foo"}, primitiveServer.get("/atomix.primitive.map.MapService/Entries"))
+
+	// The tenant can be overridden for a primitive
+	myCounter, err := client.GetCounter(context.Background(), "my-counter", WithTenant("bar"))
+	assert.NoError(t, err)
+	_, err = myCounter.Get(context.Background())
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"bar"}, primitiveServer.get("/atomix.primitive.counter.CounterService/Get"))
+	assert.Equal(t, []string{"foo", "bar"}, primitiveServer.get("/atomix.primitive.Primitive/Create"))
+
+	// Broker requests carry the tenant of the primitive being looked up
+	assert.Equal(t, []string{"foo", "bar"}, broker.get())
+	exists, err := client.Exists(context.Background(), _map.Type, "my-map")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, []string{"foo", "bar", "foo"}, broker.get())
+}
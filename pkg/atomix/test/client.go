@@ -8,6 +8,7 @@ import (
 	"context"
 	"github.com/atomix/atomix-go-client/pkg/atomix/counter"
 	"github.com/atomix/atomix-go-client/pkg/atomix/election"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-client/pkg/atomix/indexedmap"
 	"github.com/atomix/atomix-go-client/pkg/atomix/list"
 	"github.com/atomix/atomix-go-client/pkg/atomix/lock"
@@ -108,6 +109,20 @@ func (c *testClient) GetValue(ctx context.Context, name string, opts ...primitiv
 	return value.New(ctx, name, conn, c.getOpts(opts...)...)
 }
 
+func (c *testClient) Exists(ctx context.Context, t primitive.Type, name string) (bool, error) {
+	return false, errors.NewNotSupported("Exists is not supported by test clients")
+}
+
+func (c *testClient) Warmup(ctx context.Context) error {
+	// Test clients connect to primitives on demand and have no connections to establish ahead of time
+	return nil
+}
+
+func (c *testClient) Broadcast(ctx context.Context, fn func(conn *grpc.ClientConn) error) []error {
+	// Test clients connect to primitives on demand and do not track their connections
+	return nil
+}
+
 func (c *testClient) Close() error {
 	return c.Client.Stop()
 }
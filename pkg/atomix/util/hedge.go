@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"context"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
+	"time"
+)
+
+// HedgeFunc is a function invoked by Hedge
+type HedgeFunc func(ctx context.Context) (interface{}, error)
+
+type hedgeResult struct {
+	value interface{}
+	err   error
+}
+
+// Hedge invokes the given function and, if it has not succeeded within the given delay, invokes it a second time.
+// The second invocation is made immediately if the first fails before the delay. The result of whichever
+// invocation succeeds first is returned, and the context of the other invocation is cancelled; an error is
+// returned only if both invocations fail. Hedging should only be used for idempotent operations.
+func Hedge(ctx context.Context, delay time.Duration, f HedgeFunc) (interface{}, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The results channel is buffered to ensure the losing invocation never blocks
+	resultCh := make(chan hedgeResult, 2)
+	invoke := func() {
+		value, err := f(ctx)
+		resultCh <- hedgeResult{value: value, err: err}
+	}
+
+	go invoke()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	pending := 1
+	hedged := false
+	for {
+		select {
+		case result := <-resultCh:
+			pending--
+			if result.err == nil || (hedged && pending == 0) {
+				return result.value, result.err
+			}
+			if !hedged {
+				hedged = true
+				pending++
+				go invoke()
+			}
+		case <-timer.C:
+			if !hedged {
+				hedged = true
+				pending++
+				go invoke()
+			}
+		case <-ctx.Done():
+			return nil, errors.From(ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	clienterrors "github.com/atomix/atomix-go-client/pkg/atomix/errors"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHedge(t *testing.T) {
+	var calls int32
+	cancelled := make(chan struct{})
+	value, err := Hedge(context.Background(), 10*time.Millisecond, func(ctx context.Context) (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-ctx.Done()
+			close(cancelled)
+			return "slow", ctx.Err()
+		}
+		return "fast", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "fast", value)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("slow request was not cancelled")
+	}
+}
+
+func TestHedgeNotTriggered(t *testing.T) {
+	var calls int32
+	value, err := Hedge(context.Background(), time.Second, func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return "fast", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "fast", value)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	_, err = Hedge(context.Background(), time.Second, func(ctx context.Context) (interface{}, error) {
+		return nil, errors.New("failed")
+	})
+	assert.Error(t, err)
+}
+
+func TestHedgeFailure(t *testing.T) {
+	// A failed invocation is hedged immediately and the successful result is returned
+	var calls int32
+	value, err := Hedge(context.Background(), time.Minute, func(ctx context.Context) (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return nil, errors.New("failed")
+		}
+		return "hedged", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "hedged", value)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// A fast failure does not win over a slower success
+	calls = 0
+	value, err = Hedge(context.Background(), 10*time.Millisecond, func(ctx context.Context) (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			time.Sleep(50 * time.Millisecond)
+			return "slow", nil
+		}
+		return nil, errors.New("failed")
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "slow", value)
+
+	// An error is returned only once both invocations have failed
+	calls = 0
+	_, err = Hedge(context.Background(), time.Minute, func(ctx context.Context) (interface{}, error) {
+		return nil, fmt.Errorf("failed %d", atomic.AddInt32(&calls, 1))
+	})
+	assert.Error(t, err)
+	assert.Equal(t, "failed 2", err.Error())
+}
+
+func TestHedgeContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := Hedge(ctx, time.Millisecond, func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		return nil, ctx.Err()
+	})
+	assert.True(t, clienterrors.IsTimeout(err))
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
+	"strings"
+)
+
+const (
+	// keyDelimiter separates the parts of a composite key
+	keyDelimiter = '/'
+	// keyEscape escapes delimiters and escapes within the parts of a composite key
+	keyEscape = '\\'
+)
+
+// EncodeKey encodes the given parts as a composite key
+// Parts are joined with '/', and any '/' or '\' within a part is escaped with '\', so keys built from
+// arbitrary parts cannot collide and can be decoded with DecodeKey. Encoding no parts is equivalent to
+// encoding a single empty part.
+func EncodeKey(parts ...string) string {
+	var b strings.Builder
+	for i, part := range parts {
+		if i > 0 {
+			b.WriteByte(keyDelimiter)
+		}
+		for j := 0; j < len(part); j++ {
+			if part[j] == keyDelimiter || part[j] == keyEscape {
+				b.WriteByte(keyEscape)
+			}
+			b.WriteByte(part[j])
+		}
+	}
+	return b.String()
+}
+
+// DecodeKey decodes a composite key encoded with EncodeKey into its parts
+// An Invalid error is returned if the key contains an incomplete or unknown escape sequence.
+func DecodeKey(key string) ([]string, error) {
+	var parts []string
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		switch key[i] {
+		case keyDelimiter:
+			parts = append(parts, b.String())
+			b.Reset()
+		case keyEscape:
+			if i+1 == len(key) {
+				return nil, errors.NewInvalid("key '%s' ends with an incomplete escape sequence", key)
+			}
+			i++
+			if key[i] != keyDelimiter && key[i] != keyEscape {
+				return nil, errors.NewInvalid("key '%s' contains an unknown escape sequence at %d", key, i-1)
+			}
+			b.WriteByte(key[i])
+		default:
+			b.WriteByte(key[i])
+		}
+	}
+	return append(parts, b.String()), nil
+}
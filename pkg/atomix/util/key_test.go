@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestKeyRoundTrip(t *testing.T) {
+	tests := [][]string{
+		{"foo"},
+		{"foo", "bar", "baz"},
+		{""},
+		{"", ""},
+		{"foo", "", "bar"},
+		{"", "foo", ""},
+		{"foo/bar", "baz"},
+		{"foo", "bar/baz"},
+		{"/", "//", "/"},
+		{"foo\\", "bar"},
+		{"foo\\/bar", "\\"},
+		{"\\/", "/\\"},
+	}
+	for _, parts := range tests {
+		key := EncodeKey(parts...)
+		decoded, err := DecodeKey(key)
+		assert.NoError(t, err, key)
+		assert.Equal(t, parts, decoded, key)
+	}
+}
+
+func TestKeyUnambiguous(t *testing.T) {
+	assert.Equal(t, "foo/bar", EncodeKey("foo", "bar"))
+	assert.NotEqual(t, EncodeKey("foo/bar"), EncodeKey("foo", "bar"))
+	assert.NotEqual(t, EncodeKey("foo\\", "bar"), EncodeKey("foo\\/bar"))
+	assert.NotEqual(t, EncodeKey("foo", ""), EncodeKey("foo"))
+	assert.Equal(t, EncodeKey(""), EncodeKey())
+}
+
+func TestDecodeInvalidKey(t *testing.T) {
+	_, err := DecodeKey("foo\\")
+	assert.True(t, errors.IsInvalid(err))
+
+	_, err = DecodeKey("foo\\bar")
+	assert.True(t, errors.IsInvalid(err))
+}
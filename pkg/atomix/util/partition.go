@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc"
+	"strings"
+	"sync"
+)
+
+// PartitionFunc is a function invoked by ForEachPartition for each partition
+type PartitionFunc func(i int, conn *grpc.ClientConn) error
+
+// PartitionError is an error returned by a PartitionFunc for a specific partition
+type PartitionError struct {
+	// Partition is the index of the partition on which the error occurred
+	Partition int
+	// Err is the error returned for the partition
+	Err error
+}
+
+func (e *PartitionError) Error() string {
+	return fmt.Sprintf("partition %d: %v", e.Partition, e.Err)
+}
+
+// Unwrap returns the underlying partition error
+func (e *PartitionError) Unwrap() error {
+	return e.Err
+}
+
+// PartitionErrors is the aggregate of errors returned by ForEachPartition, ordered by partition
+type PartitionErrors []*PartitionError
+
+func (e PartitionErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Is returns whether the error of any partition matches the given target
+// The errors are matched with errors.Is, so errors.Is(err, target) reports whether any partition failed with
+// an error matching the target.
+func (e PartitionErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ForEachPartition invokes the given function for each partition connection, running at most parallelism
+// invocations concurrently. If parallelism is not positive, all partitions are visited concurrently.
+// Every partition is visited even if an invocation fails; if any invocation fails, the errors are returned
+// as PartitionErrors. Partitions not yet visited when the context is done fail with the context's error.
+func ForEachPartition(ctx context.Context, conns []*grpc.ClientConn, parallelism int, f PartitionFunc) error {
+	if parallelism <= 0 || parallelism > len(conns) {
+		parallelism = len(conns)
+	}
+
+	errs := make([]error, len(conns))
+	sem := make(chan struct{}, parallelism)
+	wg := &sync.WaitGroup{}
+	for i, conn := range conns {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(i int, conn *grpc.ClientConn) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = f(i, conn)
+		}(i, conn)
+	}
+	wg.Wait()
+
+	var partitionErrs PartitionErrors
+	for i, err := range errs {
+		if err != nil {
+			partitionErrs = append(partitionErrs, &PartitionError{
+				Partition: i,
+				Err:       err,
+			})
+		}
+	}
+	if len(partitionErrs) > 0 {
+		return partitionErrs
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"context"
+	"errors"
+	clienterrors "github.com/atomix/atomix-go-client/pkg/atomix/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEachPartition(t *testing.T) {
+	conns := make([]*grpc.ClientConn, 10)
+
+	var active int32
+	var maxActive int32
+	visited := &sync.Map{}
+	err := ForEachPartition(context.Background(), conns, 3, func(i int, conn *grpc.ClientConn) error {
+		n := atomic.AddInt32(&active, 1)
+		for {
+			max := atomic.LoadInt32(&maxActive)
+			if n <= max || atomic.CompareAndSwapInt32(&maxActive, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&active, -1)
+		visited.Store(i, true)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxActive), int32(3))
+
+	for i := range conns {
+		_, ok := visited.Load(i)
+		assert.True(t, ok)
+	}
+}
+
+func TestForEachPartitionErrors(t *testing.T) {
+	conns := make([]*grpc.ClientConn, 5)
+
+	failure := errors.New("failed")
+	var calls int32
+	err := ForEachPartition(context.Background(), conns, 0, func(i int, conn *grpc.ClientConn) error {
+		atomic.AddInt32(&calls, 1)
+		if i%2 == 1 {
+			return failure
+		}
+		return nil
+	})
+	assert.Error(t, err)
+	assert.Equal(t, int32(5), atomic.LoadInt32(&calls))
+
+	partitionErrs, ok := err.(PartitionErrors)
+	assert.True(t, ok)
+	assert.Len(t, partitionErrs, 2)
+	assert.Equal(t, 1, partitionErrs[0].Partition)
+	assert.Equal(t, 3, partitionErrs[1].Partition)
+	assert.True(t, errors.Is(partitionErrs[0], failure))
+
+	// The aggregate matches the errors of its partitions
+	assert.True(t, errors.Is(err, failure))
+	assert.False(t, errors.Is(err, context.Canceled))
+	assert.True(t, clienterrors.IsNotFound(PartitionErrors{
+		{Partition: 0, Err: failure},
+		{Partition: 1, Err: clienterrors.NewNotFound("not found")},
+	}))
+}
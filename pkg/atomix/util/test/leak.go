@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// leakPollInterval is the interval at which goroutines are polled while waiting for them to exit
+const leakPollInterval = 10 * time.Millisecond
+
+// NewLeakCheck creates a new LeakCheck, recording the goroutines running when it is created
+func NewLeakCheck() *LeakCheck {
+	return &LeakCheck{
+		goroutines: getGoroutines(),
+	}
+}
+
+// LeakCheck checks that goroutines started after the check was created exit
+// Goroutines belonging to the replicas and proxies started by the test harness are ignored. Create the check
+// once the primitive under test and its connection have been created, since the connection's goroutines run
+// until the connection is closed.
+type LeakCheck struct {
+	goroutines map[string]string
+}
+
+// Wait waits for goroutines started since the check was created to exit
+// If any goroutines are still running once the timeout expires, an error listing their stacks is returned.
+func (c *LeakCheck) Wait(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		leaked := c.leaked()
+		if len(leaked) == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%d goroutine(s) still running after %s:\n\n%s", len(leaked), timeout, strings.Join(leaked, "\n\n"))
+		}
+		time.Sleep(leakPollInterval)
+	}
+}
+
+// AssertCancel cancels the given context and fails the test if goroutines started since the check was
+// created do not exit within the given timeout
+func (c *LeakCheck) AssertCancel(t testing.TB, cancel context.CancelFunc, timeout time.Duration) {
+	t.Helper()
+	cancel()
+	if err := c.Wait(timeout); err != nil {
+		t.Error(err)
+	}
+}
+
+// AssertClose closes the given primitive and fails the test if goroutines started since the check was
+// created do not exit within the given timeout
+func (c *LeakCheck) AssertClose(t testing.TB, p primitive.Primitive, timeout time.Duration) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := p.Close(ctx); err != nil {
+		t.Errorf("failed to close %s: %v", p.Name(), err)
+	}
+	if err := c.Wait(timeout); err != nil {
+		t.Error(err)
+	}
+}
+
+// leaked returns the stacks of goroutines started since the check was created
+func (c *LeakCheck) leaked() []string {
+	var leaked []string
+	for id, stack := range getGoroutines() {
+		if _, ok := c.goroutines[id]; !ok && !isIgnoredGoroutine(stack) {
+			leaked = append(leaked, stack)
+		}
+	}
+	return leaked
+}
+
+// getGoroutines returns the stacks of all running goroutines, keyed by goroutine ID
+func getGoroutines() map[string]string {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	goroutines := make(map[string]string)
+	for _, stack := range bytes.Split(buf, []byte("\n\n")) {
+		header := strings.SplitN(string(stack), " ", 3)
+		if len(header) < 3 || header[0] != "goroutine" {
+			continue
+		}
+		goroutines[header[1]] = string(stack)
+	}
+	return goroutines
+}
+
+// ignoredPackages are the packages of the replicas and proxies started in-process by the test harness
+// Client-side framework packages, e.g. the retry interceptors, are not ignored, so goroutines leaked by
+// client requests are reported even while they are blocked in framework code.
+var ignoredPackages = []string{
+	"github.com/atomix/atomix-go-framework/pkg/atomix/storage/",
+	"github.com/atomix/atomix-go-framework/pkg/atomix/driver/",
+	"github.com/atomix/atomix-go-framework/pkg/atomix/stream.",
+	"github.com/atomix/atomix-go-local/",
+}
+
+// isIgnoredGoroutine returns whether the given goroutine belongs to the test framework or to the
+// replicas started in-process by the test harness, which may outlive client requests
+func isIgnoredGoroutine(stack string) bool {
+	if strings.Contains(stack, "testing.tRunner") {
+		return true
+	}
+	for _, pkg := range ignoredPackages {
+		if strings.Contains(stack, pkg) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package test
+
+import (
+	"context"
+	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	_map "github.com/atomix/atomix-go-client/pkg/atomix/map"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLeakCheckLeaked(t *testing.T) {
+	check := NewLeakCheck()
+
+	done := make(chan struct{})
+	go func() {
+		<-done
+	}()
+
+	// The goroutine is reported as leaked until it exits
+	err := check.Wait(50 * time.Millisecond)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "TestLeakCheckLeaked")
+
+	close(done)
+	assert.NoError(t, check.Wait(time.Second))
+}
+
+func TestLeakCheckPrimitive(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      _map.Type.String(),
+		Namespace: "test",
+		Name:      "TestLeakCheckPrimitive",
+	}
+
+	test := NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	m, err := _map.New(context.TODO(), "TestLeakCheckPrimitive", conn)
+	assert.NoError(t, err)
+
+	// Watch goroutines exit once the watch context is cancelled
+	check := NewLeakCheck()
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan _map.Event)
+	assert.NoError(t, m.Watch(ctx, ch))
+	_, err = m.Put(context.Background(), "foo", []byte("bar"))
+	assert.NoError(t, err)
+	<-ch
+	go func() {
+		for range ch {
+		}
+	}()
+	check.AssertCancel(t, cancel, 5*time.Second)
+
+	// Closing the primitive leaves no goroutines behind
+	check = NewLeakCheck()
+	assert.NoError(t, m.Watch(context.Background(), make(chan _map.Event)))
+	check.AssertClose(t, m, 5*time.Second)
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestIsIgnoredGoroutine(t *testing.T) {
+	// Goroutines of the in-process replicas and proxies are ignored
+	assert.True(t, isIgnoredGoroutine(`goroutine 42 [select]:
+github.com/atomix/atomix-go-framework/pkg/atomix/storage/protocol/rsm.(*Server).Query(...)`))
+	assert.True(t, isIgnoredGoroutine(`goroutine 42 [select]:
+github.com/atomix/atomix-go-framework/pkg/atomix/driver/proxy/rsm.(*Session).DoQueryStream(...)`))
+
+	// Client goroutines blocked in client-side framework code are reported
+	assert.False(t, isIgnoredGoroutine(`goroutine 42 [select]:
+github.com/atomix/atomix-go-framework/pkg/atomix/util/retry.(*serverStreamingRetryingStream).RecvMsg(...)
+created by github.com/atomix/atomix-go-client/pkg/atomix/map.(*_map).Watch`))
+}
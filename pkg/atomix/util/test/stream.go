@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// NewFakeEventStream creates a new FakeEventStream bound to the given stream context
+func NewFakeEventStream(ctx context.Context) *FakeEventStream {
+	return &FakeEventStream{
+		ctx:    ctx,
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// FakeEventStream is a fake gRPC client stream for driving watches in tests
+// Responses, errors and EOF are enqueued by the test and received by the stream's consumer in the order
+// they were enqueued. Once the queue is empty, receives block until another item is enqueued or the stream
+// context is done. The generated stream client interfaces add a typed Recv method to grpc.ClientStream, so
+// tests wrap the stream in a type whose Recv method decodes into the response type with RecvMsg:
+//
+//	type testEventsClient struct {
+//		*test.FakeEventStream
+//	}
+//
+//	func (c testEventsClient) Recv() (*api.EventsResponse, error) {
+//		response := &api.EventsResponse{}
+//		if err := c.RecvMsg(response); err != nil {
+//			return nil, err
+//		}
+//		return response, nil
+//	}
+type FakeEventStream struct {
+	ctx    context.Context
+	items  []fakeStreamItem
+	notify chan struct{}
+	mu     sync.Mutex
+}
+
+// fakeStreamItem is a response or error enqueued on a FakeEventStream
+type fakeStreamItem struct {
+	response interface{}
+	err      error
+}
+
+// Send enqueues a response to be received from the stream
+// The response must be a pointer to a message of the type the stream's consumer receives.
+func (s *FakeEventStream) Send(response interface{}) {
+	s.enqueue(fakeStreamItem{response: response})
+}
+
+// Fail enqueues an error to be returned by the stream
+// Items enqueued after the error are received by subsequent calls, so a single stream can be used to test
+// consumers that continue to receive after an error.
+func (s *FakeEventStream) Fail(err error) {
+	s.enqueue(fakeStreamItem{err: err})
+}
+
+// EOF enqueues the end of the stream, as if the server had closed the stream
+func (s *FakeEventStream) EOF() {
+	s.Fail(io.EOF)
+}
+
+func (s *FakeEventStream) enqueue(item fakeStreamItem) {
+	s.mu.Lock()
+	s.items = append(s.items, item)
+	s.mu.Unlock()
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (s *FakeEventStream) dequeue() (fakeStreamItem, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.items) == 0 {
+		return fakeStreamItem{}, false
+	}
+	item := s.items[0]
+	s.items = s.items[1:]
+	return item, true
+}
+
+// RecvMsg receives the next enqueued item into m
+// If the stream context is done before an item is enqueued, the context error is returned with the status
+// the gRPC runtime reports for a stream whose context is done.
+func (s *FakeEventStream) RecvMsg(m interface{}) error {
+	for {
+		if item, ok := s.dequeue(); ok {
+			if item.err != nil {
+				return item.err
+			}
+			dst, src := reflect.ValueOf(m), reflect.ValueOf(item.response)
+			if dst.Kind() != reflect.Ptr || dst.Type() != src.Type() {
+				return fmt.Errorf("cannot receive %T into %T", item.response, m)
+			}
+			dst.Elem().Set(src.Elem())
+			return nil
+		}
+		select {
+		case <-s.notify:
+		case <-s.ctx.Done():
+			return status.FromContextError(s.ctx.Err()).Err()
+		}
+	}
+}
+
+// SendMsg is not supported by the fake stream and returns an error
+func (s *FakeEventStream) SendMsg(m interface{}) error {
+	return fmt.Errorf("cannot send %T on a fake event stream", m)
+}
+
+// Header returns empty header metadata
+func (s *FakeEventStream) Header() (metadata.MD, error) {
+	return metadata.MD{}, nil
+}
+
+// Trailer returns empty trailer metadata
+func (s *FakeEventStream) Trailer() metadata.MD {
+	return metadata.MD{}
+}
+
+// CloseSend is a no-op, since the fake stream only receives
+func (s *FakeEventStream) CloseSend() error {
+	return nil
+}
+
+// Context returns the stream context
+func (s *FakeEventStream) Context() context.Context {
+	return s.ctx
+}
+
+var _ grpc.ClientStream = &FakeEventStream{}
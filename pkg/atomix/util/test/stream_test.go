@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package test
+
+import (
+	"context"
+	"errors"
+	mapapi "github.com/atomix/atomix-api/go/atomix/primitive/map"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestFakeEventStream(t *testing.T) {
+	stream := NewFakeEventStream(context.Background())
+	stream.Send(&mapapi.EventsResponse{Event: mapapi.Event{Type: mapapi.Event_INSERT}})
+	stream.Send(&mapapi.EventsResponse{Event: mapapi.Event{Type: mapapi.Event_UPDATE}})
+	stream.Fail(errors.New("foo"))
+	stream.EOF()
+
+	// Items are received in the order they were enqueued
+	response := &mapapi.EventsResponse{}
+	assert.NoError(t, stream.RecvMsg(response))
+	assert.Equal(t, mapapi.Event_INSERT, response.Event.Type)
+	assert.NoError(t, stream.RecvMsg(response))
+	assert.Equal(t, mapapi.Event_UPDATE, response.Event.Type)
+	assert.Equal(t, "foo", stream.RecvMsg(response).Error())
+	assert.Equal(t, io.EOF, stream.RecvMsg(response))
+
+	// Responses of the wrong type cannot be received
+	stream.Send(&mapapi.EntriesResponse{})
+	assert.Error(t, stream.RecvMsg(response))
+}
+
+func TestFakeEventStreamBlocks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := NewFakeEventStream(ctx)
+
+	// Receives block until an item is enqueued
+	errCh := make(chan error)
+	go func() {
+		errCh <- stream.RecvMsg(&mapapi.EventsResponse{})
+	}()
+	select {
+	case <-errCh:
+		t.Fatal("received from an empty stream")
+	case <-time.After(10 * time.Millisecond):
+	}
+	stream.Send(&mapapi.EventsResponse{})
+	assert.NoError(t, <-errCh)
+
+	// Receives fail once the stream context is done
+	go func() {
+		errCh <- stream.RecvMsg(&mapapi.EventsResponse{})
+	}()
+	cancel()
+	assert.Equal(t, codes.Canceled, status.Code(<-errCh))
+}
@@ -8,6 +8,7 @@ import (
 	api "github.com/atomix/atomix-api/go/atomix/primitive/value"
 	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
+	"time"
 )
 
 // Option is a value option
@@ -25,16 +26,25 @@ type SetOption interface {
 	afterSet(response *api.SetResponse)
 }
 
+// ClearOption is an option for Clear calls
+type ClearOption interface {
+	beforeClear(request *api.SetRequest)
+	afterClear(response *api.SetResponse)
+}
+
 // IfMatch updates the value if the version matches the given version
-func IfMatch(object meta.Object) SetOption {
-	return matchOption{object}
+func IfMatch(object meta.Object) MatchOption {
+	return MatchOption{object: object}
 }
 
-type matchOption struct {
+// MatchOption is an implementation of SetOption and ClearOption to specify the version for concurrency control
+type MatchOption struct {
+	SetOption
+	ClearOption
 	object meta.Object
 }
 
-func (o matchOption) beforeSet(request *api.SetRequest) {
+func (o MatchOption) beforeSet(request *api.SetRequest) {
 	proto := o.object.Meta().Proto()
 	request.Preconditions = append(request.Preconditions, api.Precondition{
 		Precondition: &api.Precondition_Metadata{
@@ -43,6 +53,70 @@ func (o matchOption) beforeSet(request *api.SetRequest) {
 	})
 }
 
-func (o matchOption) afterSet(response *api.SetResponse) {
+func (o MatchOption) afterSet(response *api.SetResponse) {
+
+}
+
+func (o MatchOption) beforeClear(request *api.SetRequest) {
+	o.beforeSet(request)
+}
+
+func (o MatchOption) afterClear(response *api.SetResponse) {
+
+}
+
+// WatchOption is an option for Watch calls
+type WatchOption interface {
+	applyWatch(options *watchOptions)
+}
+
+// watchOptions is a set of Watch options
+type watchOptions struct {
+	flushInterval time.Duration
+	persistence   *persistence
+}
+
+// persistence is a pair of callbacks persisting the last value observed by a watch
+type persistence struct {
+	store func(value []byte) error
+	load  func() ([]byte, error)
+}
+
+// WithLatestOnly returns a Watch option that delivers only the latest value received within each flush interval
+// Intermediate values superseded within an interval are dropped. The latest value is flushed when the watch
+// is closed.
+func WithLatestOnly(flush time.Duration) WatchOption {
+	return latestOnlyOption{flush: flush}
+}
+
+type latestOnlyOption struct {
+	flush time.Duration
+}
+
+func (o latestOnlyOption) applyWatch(options *watchOptions) {
+	options.flushInterval = o.flush
+}
+
+// WithPersistedLast returns a Watch option that persists the last value observed by the watch
+// The value of each event delivered by the watch is stored with the store callback. When the watch is opened,
+// the last observed value is loaded with the load callback and compared with the current value; if the value
+// changed while the consumer was not watching, e.g. across a restart, an update event carrying the current value
+// is delivered before any other event. If load returns a nil value, nothing has been persisted and an event is
+// delivered unless the value is not set. If load fails, Watch returns the error; failures to store a value are
+// logged.
+func WithPersistedLast(store func(value []byte) error, load func() ([]byte, error)) WatchOption {
+	return persistedLastOption{
+		persistence: &persistence{
+			store: store,
+			load:  load,
+		},
+	}
+}
+
+type persistedLastOption struct {
+	persistence *persistence
+}
 
+func (o persistedLastOption) applyWatch(options *watchOptions) {
+	options.persistence = o.persistence
 }
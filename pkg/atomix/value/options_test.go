@@ -16,3 +16,9 @@ func TestOptions(t *testing.T) {
 	IfMatch(meta.ObjectMeta{Revision: 1}).beforeSet(request)
 	assert.Equal(t, meta.Revision(1), meta.Revision(request.Preconditions[0].GetMetadata().Revision.Num))
 }
+
+func TestClearOptions(t *testing.T) {
+	request := &api.SetRequest{}
+	IfMatch(meta.ObjectMeta{Revision: 2}).beforeClear(request)
+	assert.Equal(t, meta.Revision(2), meta.Revision(request.Preconditions[0].GetMetadata().Revision.Num))
+}
@@ -5,14 +5,16 @@
 package value
 
 import (
+	"bytes"
 	"context"
 	api "github.com/atomix/atomix-api/go/atomix/primitive/value"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
-	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
 	"google.golang.org/grpc"
 	"io"
+	"time"
 )
 
 var log = logging.GetLogger("atomix", "client", "value")
@@ -36,8 +38,21 @@ type Value interface {
 	// Get gets the current value and version
 	Get(ctx context.Context) ([]byte, meta.ObjectMeta, error)
 
+	// CompareAndSet sets the value only if the current value equals the expected value
+	// The returned bool indicates whether the value was written. If the value was not written, the current
+	// value that was compared against the expected value is returned, so callers can retry against it
+	// without reading the value again.
+	CompareAndSet(ctx context.Context, expected, value []byte, opts ...SetOption) (bool, []byte, error)
+
+	// Clear clears the current value
+	// The value is not deleted: Clear sets it to an empty value at a new revision, which is published to
+	// watchers as an EventUpdate, and Get returns the empty value rather than an error. A cleared value cannot
+	// be distinguished from a value set to an empty value. If the IfMatch option is provided and the version
+	// does not match, a Conflict error is returned.
+	Clear(ctx context.Context, opts ...ClearOption) error
+
 	// Watch watches the value for changes
-	Watch(ctx context.Context, ch chan<- Event) error
+	Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption) error
 }
 
 // EventType is the type of a set event
@@ -50,6 +65,8 @@ const (
 
 // Event is a value change event
 type Event struct {
+	// ObjectMeta is the metadata of the updated value
+	// If the value does not carry a timestamp, the event timestamp read by primitive.GetTimestamp is used.
 	meta.ObjectMeta
 
 	// Type is the change event type
@@ -106,6 +123,44 @@ func (v *value) Set(ctx context.Context, value []byte, opts ...SetOption) (meta.
 	return meta.FromProto(response.Value.ObjectMeta), nil
 }
 
+func (v *value) CompareAndSet(ctx context.Context, expected, value []byte, opts ...SetOption) (bool, []byte, error) {
+	for {
+		current, md, err := v.Get(ctx)
+		if err != nil {
+			return false, nil, err
+		}
+		if !bytes.Equal(current, expected) {
+			return false, current, nil
+		}
+
+		// Guard the write with the revision that was read to ensure the value has not changed in the interim
+		_, err = v.Set(ctx, value, append([]SetOption{IfMatch(md)}, opts...)...)
+		if err == nil {
+			return true, value, nil
+		}
+		if !errors.IsConflict(err) {
+			return false, nil, err
+		}
+	}
+}
+
+func (v *value) Clear(ctx context.Context, opts ...ClearOption) error {
+	request := &api.SetRequest{
+		Headers: v.GetHeaders(),
+	}
+	for i := range opts {
+		opts[i].beforeClear(request)
+	}
+	response, err := v.client.Set(ctx, request)
+	if err != nil {
+		return errors.From(err)
+	}
+	for i := range opts {
+		opts[i].afterClear(response)
+	}
+	return nil
+}
+
 func (v *value) Get(ctx context.Context) ([]byte, meta.ObjectMeta, error) {
 	request := &api.GetRequest{
 		Headers: v.GetHeaders(),
@@ -117,7 +172,12 @@ func (v *value) Get(ctx context.Context) ([]byte, meta.ObjectMeta, error) {
 	return response.Value.Value, meta.FromProto(response.Value.ObjectMeta), nil
 }
 
-func (v *value) Watch(ctx context.Context, ch chan<- Event) error {
+func (v *value) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption) error {
+	options := watchOptions{}
+	for _, opt := range opts {
+		opt.applyWatch(&options)
+	}
+
 	request := &api.EventsRequest{
 		Headers: v.GetHeaders(),
 	}
@@ -126,15 +186,29 @@ func (v *value) Watch(ctx context.Context, ch chan<- Event) error {
 		return errors.From(err)
 	}
 
-	openCh := make(chan struct{})
-	go func() {
-		defer close(ch)
-		open := false
-		defer func() {
-			if !open {
-				close(openCh)
+	events := ch
+	if options.flushInterval > 0 {
+		latestCh := make(chan Event)
+		go coalesce(latestCh, ch, options.flushInterval)
+		events = latestCh
+	}
+
+	deliver := func(event Event) {
+		events <- event
+		if options.persistence != nil {
+			if err := v.InvokeCallback(func() error {
+				return options.persistence.store(event.Value)
+			}); err != nil {
+				log.Warnf("Failed to persist value %s: %v", v.Name(), err)
 			}
-		}()
+		}
+	}
+
+	handshake := primitive.NewHandshake()
+	go func() {
+		defer close(events)
+		defer handshake.Open()
+		var initial *Event
 		for {
 			response, err := stream.Recv()
 			if err != nil {
@@ -142,6 +216,10 @@ func (v *value) Watch(ctx context.Context, ch chan<- Event) error {
 					return
 				}
 				err = errors.From(err)
+				// A stream that fails before it is opened fails the watch
+				if handshake.Fail(err) {
+					return
+				}
 				if errors.IsCanceled(err) || errors.IsTimeout(err) {
 					return
 				}
@@ -149,25 +227,95 @@ func (v *value) Watch(ctx context.Context, ch chan<- Event) error {
 				return
 			}
 
-			if !open {
-				close(openCh)
-				open = true
+			// Once the stream is open, compare the persisted value with the current value
+			if options.persistence != nil && initial == nil {
+				initial, err = v.getPersistedChange(ctx, options.persistence)
+				if err != nil {
+					handshake.Fail(err)
+					return
+				}
+				handshake.Open()
+				if initial.Type == EventUpdate {
+					deliver(*initial)
+				}
 			}
+
+			handshake.Open()
+			if response.Event.Type == api.Event_NONE {
+				continue
+			}
+			objectMeta := meta.FromProto(response.Event.Value.ObjectMeta)
+			if objectMeta.Timestamp == nil {
+				objectMeta.Timestamp = primitive.GetTimestamp(response.Headers)
+			}
+
+			// Skip updates already reflected in the value read when the stream was opened
+			if initial != nil && objectMeta.Revision != 0 && objectMeta.Revision <= initial.Revision {
+				continue
+			}
+
 			switch response.Event.Type {
 			case api.Event_UPDATE:
-				ch <- Event{
-					ObjectMeta: meta.FromProto(response.Event.Value.ObjectMeta),
+				deliver(Event{
+					ObjectMeta: objectMeta,
 					Type:       EventUpdate,
 					Value:      response.Event.Value.Value,
-				}
+				})
 			}
 		}
 	}()
 
-	select {
-	case <-openCh:
-		return nil
-	case <-ctx.Done():
-		return ctx.Err()
+	return handshake.Wait(ctx)
+}
+
+// getPersistedChange reads the current value and compares it with the persisted value
+// An update event carrying the current value is returned if the value differs from the persisted value;
+// otherwise, the returned event carries the metadata of the current value and has no type.
+func (v *value) getPersistedChange(ctx context.Context, persistence *persistence) (*Event, error) {
+	var last []byte
+	if err := v.InvokeCallback(func() error {
+		var err error
+		last, err = persistence.load()
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	current, objectMeta, err := v.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	event := &Event{
+		ObjectMeta: objectMeta,
+	}
+	if (last == nil && len(current) > 0) || (last != nil && !bytes.Equal(last, current)) {
+		event.Type = EventUpdate
+		event.Value = current
+	}
+	return event, nil
+}
+
+// coalesce delivers the latest event received on in to out once per flush interval, dropping superseded events
+// The latest event is flushed and out is closed once in is closed.
+func coalesce(in <-chan Event, out chan<- Event, flushInterval time.Duration) {
+	defer close(out)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	var latest *Event
+	for {
+		select {
+		case event, ok := <-in:
+			if !ok {
+				if latest != nil {
+					out <- *latest
+				}
+				return
+			}
+			latest = &event
+		case <-ticker.C:
+			if latest != nil {
+				out <- *latest
+				latest = nil
+			}
+		}
 	}
 }
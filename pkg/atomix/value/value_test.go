@@ -7,12 +7,22 @@ package value
 import (
 	"context"
 	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	metaapi "github.com/atomix/atomix-api/go/atomix/primitive/meta"
+	api "github.com/atomix/atomix-api/go/atomix/primitive/value"
+	"github.com/atomix/atomix-go-client/pkg/atomix/errors"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
 	"github.com/atomix/atomix-go-client/pkg/atomix/util/test"
-	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/time"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"sync"
 	"testing"
+	gotime "time"
 )
 
 func TestValueOperations(t *testing.T) {
@@ -112,3 +122,408 @@ func TestValueOperations(t *testing.T) {
 
 	assert.NoError(t, test.Stop())
 }
+
+func TestValueCompareAndSet(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestValueCompareAndSet",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	value, err := New(context.TODO(), "TestValueCompareAndSet", conn)
+	assert.NoError(t, err)
+
+	_, err = value.Set(context.TODO(), []byte("foo"))
+	assert.NoError(t, err)
+
+	// The current value is returned if the swap fails
+	swapped, current, err := value.CompareAndSet(context.TODO(), []byte("bar"), []byte("baz"))
+	assert.NoError(t, err)
+	assert.False(t, swapped)
+	assert.Equal(t, "foo", string(current))
+
+	// The value is written if the current value matches
+	swapped, current, err = value.CompareAndSet(context.TODO(), current, []byte("baz"))
+	assert.NoError(t, err)
+	assert.True(t, swapped)
+	assert.Equal(t, "baz", string(current))
+
+	val, _, err := value.Get(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, "baz", string(val))
+
+	assert.NoError(t, value.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+func TestValueWatchOpen(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestValueWatchOpen",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	value, err := New(context.TODO(), "TestValueWatchOpen", conn)
+	assert.NoError(t, err)
+
+	ch := make(chan Event)
+	err = value.Watch(context.TODO(), ch)
+	assert.NoError(t, err)
+
+	_, err = value.Set(context.TODO(), []byte("foo"))
+	assert.NoError(t, err)
+
+	// The open marker completes the handshake and must not be delivered to the consumer
+	event := <-ch
+	assert.Equal(t, EventUpdate, event.Type)
+	assert.Equal(t, "foo", string(event.Value))
+
+	assert.NoError(t, value.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+type testEventsClient struct {
+	grpc.ClientStream
+	responses []*api.EventsResponse
+	err       error
+}
+
+func (c *testEventsClient) Recv() (*api.EventsResponse, error) {
+	if len(c.responses) == 0 {
+		if c.err != nil {
+			return nil, c.err
+		}
+		return nil, io.EOF
+	}
+	response := c.responses[0]
+	c.responses = c.responses[1:]
+	return response, nil
+}
+
+type testValueClient struct {
+	api.ValueServiceClient
+	events *testEventsClient
+}
+
+func (c *testValueClient) Events(ctx context.Context, request *api.EventsRequest, opts ...grpc.CallOption) (api.ValueService_EventsClient, error) {
+	return c.events, nil
+}
+
+func (c *testValueClient) Get(ctx context.Context, request *api.GetRequest, opts ...grpc.CallOption) (*api.GetResponse, error) {
+	return &api.GetResponse{}, nil
+}
+
+func TestValueWatchOpenFailure(t *testing.T) {
+	// A stream that fails before it is opened fails the watch
+	v := &value{
+		Client: primitive.NewClient(Type, "TestValueWatchOpenFailure", nil),
+		client: &testValueClient{
+			events: &testEventsClient{
+				err: status.Error(codes.Unavailable, "unavailable"),
+			},
+		},
+	}
+	ch := make(chan Event)
+	err := v.Watch(context.Background(), ch)
+	assert.True(t, errors.IsUnavailable(err))
+	_, ok := <-ch
+	assert.False(t, ok)
+
+	// A stream that fails once it has been opened closes the channel
+	v = &value{
+		Client: primitive.NewClient(Type, "TestValueWatchOpenFailure", nil),
+		client: &testValueClient{
+			events: &testEventsClient{
+				responses: []*api.EventsResponse{{}},
+				err:       status.Error(codes.Unavailable, "unavailable"),
+			},
+		},
+	}
+	ch = make(chan Event)
+	assert.NoError(t, v.Watch(context.Background(), ch))
+	_, ok = <-ch
+	assert.False(t, ok)
+}
+
+func TestValueEventTimestamp(t *testing.T) {
+	value := &value{
+		Client: primitive.NewClient(Type, "TestValueEventTimestamp", nil),
+		client: &testValueClient{
+			events: &testEventsClient{
+				responses: []*api.EventsResponse{
+					{},
+					{
+						Headers: primitiveapi.ResponseHeaders{
+							Timestamp: &metaapi.Timestamp{
+								Timestamp: &metaapi.Timestamp_LogicalTimestamp{
+									LogicalTimestamp: &metaapi.LogicalTimestamp{
+										Time: 10,
+									},
+								},
+							},
+						},
+						Event: api.Event{
+							Type: api.Event_UPDATE,
+							Value: api.Value{
+								Value: []byte("foo"),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	ch := make(chan Event)
+	err := value.Watch(context.TODO(), ch)
+	assert.NoError(t, err)
+
+	event := <-ch
+	assert.Equal(t, EventUpdate, event.Type)
+	assert.Equal(t, "foo", string(event.Value))
+	assert.Equal(t, time.NewLogicalTimestamp(10), event.Timestamp)
+
+	_, ok := <-ch
+	assert.False(t, ok)
+}
+
+func TestValueClear(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestValueClear",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	value, err := New(context.TODO(), "TestValueClear", conn)
+	assert.NoError(t, err)
+
+	md1, err := value.Set(context.TODO(), []byte("foo"))
+	assert.NoError(t, err)
+
+	md2, err := value.Set(context.TODO(), []byte("bar"))
+	assert.NoError(t, err)
+
+	// Clearing with a stale version fails
+	err = value.Clear(context.TODO(), IfMatch(md1))
+	assert.Error(t, err)
+	assert.True(t, errors.IsConflict(err))
+
+	val, _, err := value.Get(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(val))
+
+	ch := make(chan Event, 1)
+	err = value.Watch(context.TODO(), ch)
+	assert.NoError(t, err)
+
+	// Clearing with the current version succeeds
+	err = value.Clear(context.TODO(), IfMatch(md2))
+	assert.NoError(t, err)
+
+	// Clearing writes an empty value at a new revision and is published to watchers as an update
+	val, md3, err := value.Get(context.TODO())
+	assert.NoError(t, err)
+	assert.Len(t, val, 0)
+	assert.NotEqual(t, md2.Revision, md3.Revision)
+
+	event := <-ch
+	assert.Equal(t, EventUpdate, event.Type)
+	assert.Len(t, event.Value, 0)
+	assert.Equal(t, md3.Revision, event.Revision)
+
+	assert.NoError(t, value.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+type testStreamingEventsClient struct {
+	grpc.ClientStream
+	responses chan *api.EventsResponse
+}
+
+func (c *testStreamingEventsClient) Recv() (*api.EventsResponse, error) {
+	response, ok := <-c.responses
+	if !ok {
+		return nil, io.EOF
+	}
+	return response, nil
+}
+
+type testStreamingValueClient struct {
+	api.ValueServiceClient
+	events *testStreamingEventsClient
+}
+
+func (c *testStreamingValueClient) Events(ctx context.Context, request *api.EventsRequest, opts ...grpc.CallOption) (api.ValueService_EventsClient, error) {
+	return c.events, nil
+}
+
+func newTestUpdateResponse(value string) *api.EventsResponse {
+	return &api.EventsResponse{
+		Event: api.Event{
+			Type: api.Event_UPDATE,
+			Value: api.Value{
+				Value: []byte(value),
+			},
+		},
+	}
+}
+
+func TestValueWatchLatestOnly(t *testing.T) {
+	events := &testStreamingEventsClient{
+		responses: make(chan *api.EventsResponse),
+	}
+	value := &value{
+		Client: primitive.NewClient(Type, "TestValueWatchLatestOnly", nil),
+		client: &testStreamingValueClient{
+			events: events,
+		},
+	}
+
+	go func() {
+		events.responses <- &api.EventsResponse{}
+	}()
+	ch := make(chan Event)
+	err := value.Watch(context.TODO(), ch, WithLatestOnly(100*gotime.Millisecond))
+	assert.NoError(t, err)
+
+	// Only the latest value within the flush interval is delivered
+	for _, v := range []string{"foo", "bar", "baz"} {
+		events.responses <- newTestUpdateResponse(v)
+	}
+	event := <-ch
+	assert.Equal(t, "baz", string(event.Value))
+
+	// The latest value is flushed when the stream is closed
+	for _, v := range []string{"qux", "quux"} {
+		events.responses <- newTestUpdateResponse(v)
+	}
+	close(events.responses)
+	event = <-ch
+	assert.Equal(t, "quux", string(event.Value))
+
+	_, ok := <-ch
+	assert.False(t, ok)
+}
+
+// testPersistence is an in-memory store of the last value observed by a watch
+type testPersistence struct {
+	value []byte
+	mu    sync.Mutex
+}
+
+func (p *testPersistence) store(value []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.value = value
+	return nil
+}
+
+func (p *testPersistence) load() ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.value, nil
+}
+
+func TestValueWatchPersistedLast(t *testing.T) {
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestValueWatchPersistedLast",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	value, err := New(context.TODO(), "TestValueWatchPersistedLast", conn)
+	assert.NoError(t, err)
+
+	_, err = value.Set(context.TODO(), []byte("foo"))
+	assert.NoError(t, err)
+
+	// No event is delivered if the value is unchanged since it was persisted
+	persistence := &testPersistence{value: []byte("foo")}
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan Event)
+	err = value.Watch(ctx, ch, WithPersistedLast(persistence.store, persistence.load))
+	assert.NoError(t, err)
+
+	_, err = value.Set(context.TODO(), []byte("bar"))
+	assert.NoError(t, err)
+	event := <-ch
+	assert.Equal(t, EventUpdate, event.Type)
+	assert.Equal(t, "bar", string(event.Value))
+	cancel()
+	for range ch {
+	}
+	loaded, err := persistence.load()
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(loaded))
+
+	// An event is delivered if the value changed since it was persisted
+	_, err = value.Set(context.TODO(), []byte("baz"))
+	assert.NoError(t, err)
+	ctx, cancel = context.WithCancel(context.Background())
+	ch = make(chan Event)
+	err = value.Watch(ctx, ch, WithPersistedLast(persistence.store, persistence.load))
+	assert.NoError(t, err)
+	event = <-ch
+	assert.Equal(t, EventUpdate, event.Type)
+	assert.Equal(t, "baz", string(event.Value))
+
+	_, err = value.Set(context.TODO(), []byte("qux"))
+	assert.NoError(t, err)
+	event = <-ch
+	assert.Equal(t, "qux", string(event.Value))
+	cancel()
+	for range ch {
+	}
+
+	// A failure to load the persisted value fails the watch
+	err = value.Watch(context.Background(), make(chan Event), WithPersistedLast(persistence.store, func() ([]byte, error) {
+		return nil, errors.NewUnavailable("unavailable")
+	}))
+	assert.True(t, errors.IsUnavailable(err))
+
+	assert.NoError(t, value.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+func TestValueWatchPersistedLastUnset(t *testing.T) {
+	value := &value{
+		Client: primitive.NewClient(Type, "TestValueWatchPersistedLastUnset", nil),
+		client: &testValueClient{
+			events: &testEventsClient{
+				responses: []*api.EventsResponse{{}},
+			},
+		},
+	}
+
+	// No event is delivered if nothing was persisted and the value is not set
+	persistence := &testPersistence{}
+	ch := make(chan Event)
+	err := value.Watch(context.TODO(), ch, WithPersistedLast(persistence.store, persistence.load))
+	assert.NoError(t, err)
+	_, ok := <-ch
+	assert.False(t, ok)
+}